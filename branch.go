@@ -0,0 +1,172 @@
+package gulp
+
+import (
+	"math"
+	"time"
+)
+
+// integerTolerance How far a value may be from an integer, or from zero in a special ordered set, and still count as
+// one
+const integerTolerance = 1e-6
+
+// branchBound A bound added to the relaxation by a branch, Variable {ConstraintType} Bound
+type branchBound struct {
+	Variable       LpVariable
+	ConstraintType LpConstraintType
+	Bound          float64
+}
+
+// solveBranchAndBound Solve a linear program with integer or binary variables, or special ordered sets, by branch and
+// bound. Each node solves the linear relaxation with the bounds of its branches added as rows, and branches on the most
+// fractional integer variable, or failing that on the first violated set, until the relaxation is integral or no better
+// than the best solution found. The duals and reduced costs are those of the relaxation the best solution came from.
+// The iteration and time limits cover the whole search, and if either is reached the best solution found so far is
+// returned with its status.
+func (lp *LinearProgram) solveBranchAndBound(config solverConfig) *Result {
+	start := time.Now()
+	sense := float64(lp.Sense)
+
+	// root The first relaxation, and stopped the one that ended the search early
+	var best, root, stopped *Result
+	iterations := 0
+	status := LpStatusOptimal
+	nodes := [][]branchBound{nil}
+	for len(nodes) > 0 && status == LpStatusOptimal {
+		bounds := nodes[len(nodes)-1]
+		nodes = nodes[:len(nodes)-1]
+
+		node := config
+		node.iterationLimit = config.iterationLimit - iterations
+		if node.iterationLimit <= 0 {
+			status = LpStatusIterationLimit
+			break
+		}
+		if config.timeLimit > 0 {
+			node.timeLimit = config.timeLimit - time.Since(start)
+			if node.timeLimit <= 0 {
+				status = LpStatusTimeLimit
+				break
+			}
+		}
+		result := lp.relaxation(bounds).solveWith(node)
+		iterations += result.iterations
+		if root == nil {
+			root = result
+		}
+
+		switch result.status {
+		case LpStatusOptimal:
+		case LpStatusInfeasible:
+			continue
+		default:
+			// An unbounded relaxation leaves the integer program unbounded or infeasible, either way the search stops
+			status, stopped = result.status, result
+			continue
+		}
+		if best != nil && sense*(result.objectiveValue-best.objectiveValue) <= integerTolerance*math.Max(1, math.Abs(best.objectiveValue)) {
+			continue
+		}
+
+		branches := lp.branches(result)
+		if branches == nil {
+			best = result
+			continue
+		}
+		// The first branch is pushed last so it is searched first
+		for k := len(branches) - 1; k >= 0; k-- {
+			nodes = append(nodes, append(append([]branchBound{}, bounds...), branches[k]...))
+		}
+	}
+
+	result := best
+	switch {
+	case best != nil && status != LpStatusUnbounded:
+		result.status = status
+	case stopped != nil:
+		result = stopped
+	case root != nil:
+		// Every branch was infeasible, or a limit was reached before a solution was found
+		if status == LpStatusOptimal {
+			status = LpStatusInfeasible
+		}
+		result = root
+		result.status, result.objectiveValue = status, math.NaN()
+	default:
+		// A limit was reached before the first relaxation was solved
+		objective, constraints := lp.standardForm()
+		result = newResult(lp, constraints, newTableau(objective, constraints, lp.Sense), status, 0, 0)
+	}
+
+	result.restrict(lp)
+	result.iterations = iterations
+	result.solveTime = time.Since(start)
+	return result
+}
+
+// relaxation Build a copy of the linear program without its integer categories or special ordered sets, with binary
+// variables bounded by one and the bounds of the branches added as rows after its constraints
+func (lp *LinearProgram) relaxation(bounds []branchBound) *LinearProgram {
+	model := *lp
+	model.categories, model.SpecialOrderedSets = nil, nil
+	model.Constraints = make([]*LpConstraint, len(lp.Constraints))
+	for i, c := range lp.Constraints {
+		row := *c
+		row.Terms = append([]LpTerm{}, c.Terms...)
+		model.Constraints[i] = &row
+	}
+
+	for _, v := range lp.variables {
+		if lp.Category(v) == LpBinary {
+			model.AddNamedConstraint(model.nextConstraintName(), NewExpression([]LpTerm{NewTerm(1, v)}), LpConstraintLE, 1)
+		}
+	}
+	for _, b := range bounds {
+		model.AddNamedConstraint(model.nextConstraintName(), NewExpression([]LpTerm{NewTerm(1, b.Variable)}), b.ConstraintType, b.Bound)
+	}
+	return &model
+}
+
+// branches Get the bounds each branch adds to rule out the solution of a relaxation, nil if it is already integral
+// and satisfies every set
+func (lp *LinearProgram) branches(result *Result) [][]branchBound {
+	variable, fraction := LpVariable{}, integerTolerance
+	for _, v := range lp.variables {
+		if lp.Category(v) == LpContinuous {
+			continue
+		}
+		value := result.Value(v)
+		if f := math.Abs(value - math.Round(value)); f > fraction {
+			variable, fraction = v, f
+		}
+	}
+	if fraction > integerTolerance {
+		value := result.Value(variable)
+		return [][]branchBound{
+			{{variable, LpConstraintLE, math.Floor(value)}},
+			{{variable, LpConstraintGE, math.Ceil(value)}},
+		}
+	}
+
+	for _, s := range lp.SpecialOrderedSets {
+		if s.violation(result.Value) <= integerTolerance {
+			continue
+		}
+		// Each branch fixes the variables the other keeps at zero
+		split := s.split(result.Value, integerTolerance)
+		right := split + 1
+		if s.Type == LpSOS2 {
+			right = split
+		}
+		var below, above []branchBound
+		for k, v := range s.Variables {
+			if k > split {
+				below = append(below, branchBound{v, LpConstraintLE, 0})
+			}
+			if k < right {
+				above = append(above, branchBound{v, LpConstraintLE, 0})
+			}
+		}
+		return [][]branchBound{below, above}
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+// Command gulp solves a linear program read from a file or stdin and prints the solution.
+//
+//	gulp [flags] [model.lp|model.mps|model.json]
+//	gulp -i [model file]
+//
+// The model format is taken from the file extension, or from -format when reading stdin. With -i, gulp starts an
+// interactive shell for building, solving and pivoting through a model, starting from the model file if one is given.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chriso345/gulp"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run Parse the arguments, solve the model and write the report, returning the exit code
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("gulp", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", "", "model format: lp, mps or json (default from the file extension, lp for stdin)")
+	algorithm := flags.String("algorithm", "simplex", "solve algorithm: simplex or exact")
+	tolerance := flags.Float64("tolerance", gulp.DefaultTolerance, "values within tolerance of zero are treated as zero")
+	timeLimit := flags.Duration("time-limit", 0, "stop after this long, for example 10s (0 means no limit)")
+	iterations := flags.Int("iterations", gulp.DefaultIterationLimit, "stop after this many iterations")
+	output := flags.String("output", "text", "report format: text, csv or markdown")
+	verbose := flags.Bool("v", false, "log each iteration's tableau to stderr")
+	presolve := flags.Bool("presolve", false, "presolve the model before solving it")
+	scale := flags.Bool("scale", false, "scale the constraint matrix before solving")
+	explain := flags.Bool("iis", false, "when the model is infeasible, print a minimal set of conflicting constraints to stderr")
+	stats := flags.Bool("stats", false, "print model statistics and warnings instead of solving")
+	verify := flags.Bool("verify", false, "check an optimal solution against the model and print the largest violations to stderr")
+	interactive := flags.Bool("i", false, "start an interactive shell, reading commands from stdin")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: gulp [flags] [model file]\n\nReads the model from stdin when no file is given.\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+
+	report, ok := map[string]gulp.ReportFormat{
+		"text":     gulp.ReportText,
+		"csv":      gulp.ReportCSV,
+		"markdown": gulp.ReportMarkdown,
+	}[*output]
+	if !ok {
+		fmt.Fprintf(stderr, "gulp: unknown output format %q\n", *output)
+		return 2
+	}
+
+	options := []gulp.SolverOption{
+		gulp.WithTolerance(*tolerance),
+		gulp.WithIterationLimit(*iterations),
+		gulp.WithTimeLimit(*timeLimit),
+	}
+	switch *algorithm {
+	case "simplex":
+	case "exact":
+		options = append(options, gulp.WithExactArithmetic())
+	default:
+		fmt.Fprintf(stderr, "gulp: unknown algorithm %q\n", *algorithm)
+		return 2
+	}
+	if *presolve {
+		options = append(options, gulp.WithPresolve())
+	}
+	if *scale {
+		options = append(options, gulp.WithScaling())
+	}
+	if *verbose {
+		options = append(options, gulp.WithObserver(func(it gulp.Iteration) {
+			if it.Kind == gulp.IterationBound {
+				fmt.Fprintf(stderr, "Iteration %d: %v moves to the other end of its range\n%v\n", it.Number, it.Entering, it.Tableau)
+				return
+			}
+			fmt.Fprintf(stderr, "Iteration %d: %v enters, %v leaves\n%v\n", it.Number, it.Entering, it.Leaving, it.Tableau)
+		}))
+	}
+
+	if *interactive && flags.NArg() == 0 {
+		return runREPL(nil, stdin, stdout, options)
+	}
+
+	input := stdin
+	if flags.NArg() == 1 {
+		file, err := os.Open(flags.Arg(0))
+		if err != nil {
+			fmt.Fprintf(stderr, "gulp: %v\n", err)
+			return 1
+		}
+		defer file.Close()
+		input = file
+		if *format == "" {
+			*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(flags.Arg(0))), ".")
+		}
+	}
+
+	lp, err := readModel(input, *format)
+	if err != nil {
+		fmt.Fprintf(stderr, "gulp: %v\n", err)
+		return 1
+	}
+	if *interactive {
+		return runREPL(lp, stdin, stdout, options)
+	}
+	if *stats {
+		fmt.Fprint(stdout, lp.Stats())
+		return 0
+	}
+
+	if *scale && *verbose {
+		scaled := lp.Scale(gulp.ScaleGeometricEquilibration)
+		fmt.Fprintf(stderr, "Coefficient range %v, scaled to %v\n", scaled.Before, scaled.After)
+	}
+
+	start := time.Now()
+	result := lp.Solve(options...)
+	if *verbose {
+		fmt.Fprintf(stderr, "Solved in %d iterations, %v\n", result.Iterations(), time.Since(start))
+	}
+
+	if err := result.WriteReport(stdout, report); err != nil {
+		fmt.Fprintf(stderr, "gulp: %v\n", err)
+		return 1
+	}
+	if *explain && result.Status() == gulp.LpStatusInfeasible {
+		iis, err := lp.ComputeIIS()
+		if err != nil {
+			fmt.Fprintf(stderr, "gulp: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stderr, "Conflicting constraints and bounds:\n%v", iis)
+	}
+	if *verify && result.Status() == gulp.LpStatusOptimal {
+		verification, err := lp.Verify(result)
+		if err != nil {
+			fmt.Fprintf(stderr, "gulp: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stderr, "Verification:\n%v", verification)
+		if !verification.OK(*tolerance) {
+			fmt.Fprintf(stderr, "gulp: solution fails verification\n")
+			return 1
+		}
+	}
+	return 0
+}
+
+// readModel Read the model in the named format, lp is assumed when no format is given
+func readModel(r io.Reader, format string) (*gulp.LinearProgram, error) {
+	switch format {
+	case "", "lp":
+		return gulp.ReadLP(r)
+	case "mps":
+		return gulp.ReadMPS(r)
+	case "json":
+		return gulp.ReadJSON(r)
+	}
+	return nil, fmt.Errorf("unknown model format %q", format)
+}
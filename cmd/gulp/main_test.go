@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const applesLP = `Maximize
+ profit: 7 Apples + 6 Bananas
+Subject To
+ water: 2 Apples + 4 Bananas <= 16
+ land: 3 Apples + 2 Bananas <= 12
+End
+`
+
+func TestRunStdin(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-output", "csv"}, strings.NewReader(applesLP), &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %v: %v", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "objective,,32,,,,") || !strings.Contains(stdout.String(), "variable,Bananas,3,0,,,") {
+		t.Errorf("Unexpected report\n%v", stdout.String())
+	}
+}
+
+func TestRunStats(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-stats"}, strings.NewReader(applesLP), &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %v: %v", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Non-zeros:        4 (density 100%)") || strings.Contains(stdout.String(), "Status") {
+		t.Errorf("Unexpected statistics\n%v", stdout.String())
+	}
+}
+
+func TestRunIIS(t *testing.T) {
+	model := "Maximize\n x + y\nSubject To\n total: x + y <= 2\n xmin: x >= 3\nEnd"
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-iis"}, strings.NewReader(model), &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %v: %v", code, stderr.String())
+	}
+	if stderr.String() != "Conflicting constraints and bounds:\ntotal\nxmin\ny >= 0\n" {
+		t.Errorf("Unexpected output\n%v", stderr.String())
+	}
+}
+
+func TestRunVerify(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-verify"}, strings.NewReader(applesLP), &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %v: %v", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "Verification:\nPrimal feasibility:       0\n") {
+		t.Errorf("Unexpected output\n%v", stderr.String())
+	}
+}
+
+func TestRunFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "apples.json")
+	model := `{"sense": "max", "objective": [{"name": "x", "coefficient": 1}],
+  "constraints": [{"name": "cap", "terms": [{"name": "x", "coefficient": 2}], "type": "<=", "rhs": 1}]}`
+	if err := os.WriteFile(path, []byte(model), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-algorithm", "exact", "-v", path}, nil, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %v: %v", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Objective:  1/2") {
+		t.Errorf("Unexpected report\n%v", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "Iteration 1: x enters, s1 leaves") {
+		t.Errorf("Expected the iteration log, got\n%v", stderr.String())
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{"-output", "xml"},
+		{"-algorithm", "interior"},
+		{"-format", "xml"},
+		{filepath.Join(t.TempDir(), "missing.lp")},
+	} {
+		var stdout, stderr bytes.Buffer
+		if code := run(args, strings.NewReader(applesLP), &stdout, &stderr); code == 0 {
+			t.Errorf("Expected a non-zero exit code for %v", args)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run(nil, strings.NewReader("Maximize\n x + 1\nEnd"), &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "constant terms") {
+		t.Errorf("Expected a parse error, got %v: %v", code, stderr.String())
+	}
+}
+
+func TestREPL(t *testing.T) {
+	session := `var Apples Bananas
+max: 7 Apples + 6 Bananas
+water: 2 Apples + 4 Bananas <= 16
+land: 3 Apples + 2 Bananas <= 12
+candidates
+ratios Apples
+pivot Apples s1
+pivot Apples s2
+pivot
+pivot
+undo
+reset
+bogus
+solve
+explain
+quit
+show model
+`
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-i"}, strings.NewReader(session), &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %v: %v", code, stderr.String())
+	}
+
+	out := stdout.String()
+	for _, expected := range []string{
+		"Apples |   7 |      1 |     2/3 |  0 |  1/3 |  4",
+		"The tableau is optimal",
+		"Bananas |   6 |      0 |       1 |  3/8 | -1/4 |  3",
+		"error: unknown command \"bogus\"",
+		"Apples (C-Z = 7)",
+		"s2: 4",
+		"error: pivot would make the tableau infeasible: s2 would be -12",
+		"Objective:  32",
+		"water is binding: raising its right hand side by one changes the objective by 0.5",
+		"Apples = 2: raising it would not change the objective",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected %q in the session output\n%v", expected, out)
+		}
+	}
+	if strings.Contains(out, "Maximise") {
+		t.Errorf("Expected the session to end at quit\n%v", out)
+	}
+}
+
+func TestREPLLabels(t *testing.T) {
+	// Only the objective commands take a colon, any other word before one names a constraint
+	session := `var x y
+max:x + y
+solve: x <= 3
+show: y <= 2
+max: x + y <= 4
+drop solve
+show model
+`
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-i"}, strings.NewReader(session), &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %v: %v", code, stderr.String())
+	}
+	out := stdout.String()
+	for _, expected := range []string{"Max: 1 * x + 1 * y\n\t1 * y <= 2\n", "error: "} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected %q in the session output\n%v", expected, out)
+		}
+	}
+	if strings.Contains(out, "Objective:") || strings.Contains(out, "x <= 3") {
+		t.Errorf("Expected labelled constraints rather than commands\n%v", out)
+	}
+}
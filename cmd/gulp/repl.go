@@ -0,0 +1,348 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/chriso345/gulp"
+)
+
+const replHelp = `Commands:
+  var x y ...             declare variables, fixing their column order
+  max <expr>              set the objective, e.g. max 7 Apples + 6 Bananas
+  min <expr>
+  [name:] <expr> <= rhs   add a constraint, also >= and =
+  drop <name>             remove a constraint
+  show [model|tableau|solution]
+  solve                   solve the model and print the solution
+  pivot                   make one simplex pivot on the tableau
+  pivot <enter> <leave>   pivot on a chosen entering and leaving variable
+  candidates              list the variables that would improve the objective
+  ratios <var>            show the ratio test for a variable entering the basis
+  undo                    undo the last pivot
+  reset                   go back to the initial tableau
+  explain                 explain the sensitivity of the last solution
+  help
+  quit
+`
+
+// repl An interactive session building and solving a single linear program
+type repl struct {
+	lp      gulp.LinearProgram
+	options []gulp.SolverOption
+	result  *gulp.Result
+	tableau *gulp.Tableau
+	history []*gulp.Tableau
+	out     io.Writer
+}
+
+// runREPL Read commands from in until it is exhausted or the user quits, starting from lp if it is not nil
+func runREPL(lp *gulp.LinearProgram, in io.Reader, out io.Writer, options []gulp.SolverOption) int {
+	r := &repl{lp: gulp.NewLinearProgram(), options: options, out: out}
+	if lp != nil {
+		r.lp = *lp
+	}
+	fmt.Fprintf(out, "gulp interactive shell, type help for a list of commands\n")
+
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			break
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "quit" || line == "exit" {
+			break
+		}
+		if err := r.execute(line); err != nil {
+			fmt.Fprintf(out, "error: %v\n", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(out, "error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// execute Run a single command, turning panics from the library into errors
+func (r *repl) execute(line string) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("%v", p)
+		}
+	}()
+
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+	command, rest, _ := strings.Cut(line, " ")
+	if label, expression, ok := strings.Cut(line, ":"); ok && !strings.ContainsAny(strings.TrimSpace(label), " \t<>=") {
+		// A word and a colon set the objective, as in max: x + y, or otherwise name a constraint
+		command, rest = strings.TrimSpace(label), expression
+		if !isObjectiveCommand(command) {
+			return r.addConstraint(line)
+		}
+	}
+	rest = strings.TrimSpace(rest)
+	switch strings.ToLower(command) {
+	case "help":
+		fmt.Fprint(r.out, replHelp)
+	case "var":
+		if rest == "" {
+			return fmt.Errorf("var needs at least one name")
+		}
+		for _, name := range strings.Fields(rest) {
+			r.lp.AddVariable(gulp.NewVariable(name))
+		}
+		r.modelChanged()
+	case "max", "maximise", "maximize", "min", "minimise", "minimize":
+		objective, err := gulp.ParseExpression(rest)
+		if err != nil {
+			return err
+		}
+		if len(objective.Terms) == 0 {
+			return fmt.Errorf("objective function is empty")
+		}
+		sense := gulp.LpMaximise
+		if strings.HasPrefix(strings.ToLower(command), "min") {
+			sense = gulp.LpMinimise
+		}
+		r.lp.AddObjective(sense, objective)
+		r.modelChanged()
+	case "drop":
+		r.lp.RemoveConstraint(rest)
+		r.modelChanged()
+	case "show":
+		return r.show(rest)
+	case "solve":
+		if err := r.requireObjective(); err != nil {
+			return err
+		}
+		r.result = r.lp.Solve(r.options...)
+		return r.result.WriteText(r.out)
+	case "pivot":
+		if rest != "" {
+			return r.pivotAt(strings.Fields(rest))
+		}
+		return r.pivot()
+	case "candidates":
+		return r.candidates()
+	case "ratios":
+		return r.ratios(rest)
+	case "undo":
+		if len(r.history) == 0 {
+			return fmt.Errorf("there is no pivot to undo")
+		}
+		r.tableau = r.history[len(r.history)-1]
+		r.history = r.history[:len(r.history)-1]
+		fmt.Fprint(r.out, r.tableau.Format(true))
+	case "reset":
+		r.tableau, r.history = nil, nil
+		return r.show("tableau")
+	case "explain":
+		return r.explain()
+	default:
+		if !strings.ContainsAny(line, "<>=") {
+			return fmt.Errorf("unknown command %q, type help for a list of commands", command)
+		}
+		return r.addConstraint(line)
+	}
+	return nil
+}
+
+// isObjectiveCommand Check whether the command sets the objective function
+func isObjectiveCommand(command string) bool {
+	switch strings.ToLower(command) {
+	case "max", "maximise", "maximize", "min", "minimise", "minimize":
+		return true
+	}
+	return false
+}
+
+// modelChanged Forget the tableau and solution, which no longer describe the model
+func (r *repl) modelChanged() {
+	r.result, r.tableau, r.history = nil, nil, nil
+}
+
+func (r *repl) requireObjective() error {
+	if len(r.lp.ObjectiveFunction.Terms) == 0 {
+		return fmt.Errorf("no objective, set one with max or min")
+	}
+	return nil
+}
+
+func (r *repl) addConstraint(line string) error {
+	if err := r.requireObjective(); err != nil {
+		return err
+	}
+	c, err := gulp.ParseConstraint(line)
+	if err != nil {
+		return err
+	}
+	if c.Name == "" {
+		r.lp.AddConstraint(gulp.NewExpression(c.Terms), c.ConstraintType, c.RightHandSide)
+	} else {
+		r.lp.AddNamedConstraint(c.Name, gulp.NewExpression(c.Terms), c.ConstraintType, c.RightHandSide)
+	}
+	r.modelChanged()
+	return nil
+}
+
+func (r *repl) show(what string) error {
+	switch what {
+	case "", "model":
+		if err := r.requireObjective(); err != nil {
+			return err
+		}
+		fmt.Fprintln(r.out, r.lp.String())
+	case "tableau":
+		tableau, err := r.sessionTableau()
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(r.out, tableau.Format(true))
+	case "solution":
+		if r.result == nil {
+			return fmt.Errorf("the model has not been solved, use solve")
+		}
+		return r.result.WriteText(r.out)
+	default:
+		return fmt.Errorf("cannot show %q, use model, tableau or solution", what)
+	}
+	return nil
+}
+
+// pivot Make one pivot of the simplex method on the session tableau, keeping the previous tableau for undo
+func (r *repl) pivot() error {
+	if _, err := r.sessionTableau(); err != nil {
+		return err
+	}
+	if r.tableau.IsOptimal() {
+		fmt.Fprintln(r.out, "The tableau is optimal")
+		return nil
+	}
+
+	previous := r.tableau.Clone()
+	if !r.tableau.Pivot() {
+		fmt.Fprintln(r.out, "No pivot row, the problem is unbounded")
+		return nil
+	}
+	r.history = append(r.history, previous)
+	fmt.Fprint(r.out, r.tableau.Format(true))
+	if r.tableau.IsOptimal() {
+		fmt.Fprintln(r.out, "The tableau is optimal")
+	}
+	return nil
+}
+
+// sessionTableau Get the session tableau, building the initial tableau if there is none
+func (r *repl) sessionTableau() (*gulp.Tableau, error) {
+	if err := r.requireObjective(); err != nil {
+		return nil, err
+	}
+	if r.tableau == nil {
+		r.tableau = gulp.NewTableau(&r.lp)
+	}
+	return r.tableau, nil
+}
+
+// pivotAt Pivot on the variables chosen by the user, letting the library check the choice
+func (r *repl) pivotAt(names []string) error {
+	if len(names) != 2 {
+		return fmt.Errorf("pivot needs an entering and a leaving variable")
+	}
+	tableau, err := r.sessionTableau()
+	if err != nil {
+		return err
+	}
+	column := tableau.ColumnIndex(names[0])
+	if column < 0 {
+		return fmt.Errorf("there is no column %q", names[0])
+	}
+	row := tableau.RowIndex(names[1])
+	if row < 0 {
+		return fmt.Errorf("%q is not in the basis", names[1])
+	}
+
+	previous := tableau.Clone()
+	if err := tableau.PivotAt(row, column); err != nil {
+		return err
+	}
+	r.history = append(r.history, previous)
+	fmt.Fprint(r.out, tableau.Format(true))
+	if tableau.IsOptimal() {
+		fmt.Fprintln(r.out, "The tableau is optimal")
+	}
+	return nil
+}
+
+func (r *repl) candidates() error {
+	tableau, err := r.sessionTableau()
+	if err != nil {
+		return err
+	}
+	columns := tableau.CandidateColumns()
+	if len(columns) == 0 {
+		fmt.Fprintln(r.out, "The tableau is optimal")
+		return nil
+	}
+	for _, column := range columns {
+		fmt.Fprintf(r.out, "%v (C-Z = %v)\n", tableau.NamesRow[column], strconv.FormatFloat(tableau.CZRow.Values[column], 'g', 10, 64))
+	}
+	return nil
+}
+
+func (r *repl) ratios(name string) error {
+	tableau, err := r.sessionTableau()
+	if err != nil {
+		return err
+	}
+	column := tableau.ColumnIndex(name)
+	if column < 0 {
+		return fmt.Errorf("there is no column %q", name)
+	}
+	for i, ratio := range tableau.RatioTest(column) {
+		if math.IsInf(ratio, 0) {
+			fmt.Fprintf(r.out, "%v: -\n", tableau.BasisNames[i])
+		} else {
+			fmt.Fprintf(r.out, "%v: %v\n", tableau.BasisNames[i], strconv.FormatFloat(ratio, 'g', 10, 64))
+		}
+	}
+	return nil
+}
+
+// explain Describe in words what the reduced costs and duals of the last solution mean
+func (r *repl) explain() error {
+	if r.result == nil {
+		return fmt.Errorf("the model has not been solved, use solve")
+	}
+	if r.result.Status() != gulp.LpStatusOptimal {
+		return fmt.Errorf("there is no sensitivity information for a %v solution", r.result.Status())
+	}
+
+	const tolerance = 1e-9
+	format := func(v float64) string { return strconv.FormatFloat(v, 'g', 10, 64) }
+	for _, v := range r.result.Variables() {
+		value, reducedCost := r.result.Value(v), r.result.ReducedCost(v)
+		if math.Abs(reducedCost) <= tolerance {
+			fmt.Fprintf(r.out, "%v = %v: raising it would not change the objective\n", v.Name, format(value))
+		} else {
+			fmt.Fprintf(r.out, "%v = %v: each unit of %v would change the objective by %v\n", v.Name, format(value), v.Name, format(reducedCost))
+		}
+	}
+	for _, name := range r.result.Constraints() {
+		slack, dual := r.result.Slack(name), r.result.Dual(name)
+		if math.Abs(slack) > tolerance {
+			fmt.Fprintf(r.out, "%v has slack %v: small changes to its right hand side do not change the objective\n", name, format(slack))
+		} else {
+			fmt.Fprintf(r.out, "%v is binding: raising its right hand side by one changes the objective by %v\n", name, format(dual))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package gulp
+
+import (
+	"fmt"
+	"math"
+)
+
+// LpConstraint A named constraint, kept exactly as it was added to the linear program
+type LpConstraint struct {
+	Name           string
+	ConstraintType LpConstraintType
+	Terms          []LpTerm
+	RightHandSide  float64
+
+	// Elastic constraints may be violated, at a cost of Penalty per unit of violation, see SetElastic
+	Elastic bool
+	Penalty float64
+
+	// Range The width of a range constraint, see AddRangeConstraint. A constraint with a Range is kept as
+	// terms <= RightHandSide with RightHandSide - Range <= terms as well.
+	Range float64
+}
+
+// AddNamedConstraint Add a constraint under the given name and return a handle to it. The expression must not have a
+// constant term, use AddNamedConstraintExpr to move one to the right hand side.
+func (lp *LinearProgram) AddNamedConstraint(name string, constraint LpExpression, constraintType LpConstraintType, rightHandSide float64) *LpConstraint {
+	// Panic if objective function is not set
+	if len(lp.ObjectiveFunction.Terms) == 0 {
+		panic("Objective function not set")
+	}
+	if name == "" {
+		panic("Constraint name must not be empty")
+	}
+	if lp.Constraint(name) != nil {
+		panic(fmt.Sprintf("Constraint %q already exists", name))
+	}
+	if constraint.Constant != 0 {
+		panic(fmt.Sprintf("Constraint %q must not have a constant term", name))
+	}
+
+	lp.registerTerms(constraint.Terms)
+	c := &LpConstraint{
+		Name:           name,
+		ConstraintType: constraintType,
+		Terms:          append([]LpTerm{}, constraint.Terms...),
+		RightHandSide:  rightHandSide,
+	}
+	lp.Constraints = append(lp.Constraints, c)
+	return c
+}
+
+// AddNamedConstraintExpr Add a constraint with expressions on both sides under the given name. The terms of rhs are
+// moved to the left and the constants to the right, with the terms of each variable combined, so the constraint is
+// kept, and printed, as terms {type} constant like any other.
+func (lp *LinearProgram) AddNamedConstraintExpr(name string, lhs LpExpression, constraintType LpConstraintType, rhs LpExpression) *LpConstraint {
+	terms := combineTerms(append(append([]LpTerm{}, lhs.Terms...), scaleTerms(rhs.Terms, -1)...))
+	return lp.AddNamedConstraint(name, NewExpression(terms), constraintType, rhs.Constant-lhs.Constant)
+}
+
+// AddNamedRangeConstraint Add the constraint lower <= expression <= upper under the given name. It is one row with one
+// dual, whose slack the solver bounds by upper - lower, rather than a pair of inequalities. The expression must not
+// have a constant term. Equal bounds give an equality.
+func (lp *LinearProgram) AddNamedRangeConstraint(name string, constraint LpExpression, lower, upper float64) *LpConstraint {
+	if !(lower <= upper) || math.IsInf(lower, 0) || math.IsInf(upper, 0) {
+		panic(fmt.Sprintf("Constraint %q: range must be finite with lower <= upper, got %v and %v", name, lower, upper))
+	}
+	if lower == upper {
+		return lp.AddNamedConstraint(name, constraint, LpConstraintEQ, upper)
+	}
+	c := lp.AddNamedConstraint(name, constraint, LpConstraintLE, upper)
+	c.Range = upper - lower
+	return c
+}
+
+// lowerBound Get the lower end of a range constraint
+func (c *LpConstraint) lowerBound() float64 {
+	return c.RightHandSide - c.Range
+}
+
+// rangeViolation Get how far value is outside upper - width <= value <= upper, zero within the default tolerance
+func rangeViolation(value, upper, width float64) float64 {
+	return math.Max(violation(value, LpConstraintLE, upper), violation(value, LpConstraintGE, upper-width))
+}
+
+// combineTerms Sum the coefficients of each variable, in the order the variables first appear, dropping those that
+// cancel out
+func combineTerms(terms []LpTerm) []LpTerm {
+	index := make(map[string]int)
+	var combined []LpTerm
+	for _, t := range terms {
+		if i, ok := index[t.Variable.Name]; ok {
+			combined[i].Coefficient += t.Coefficient
+			continue
+		}
+		index[t.Variable.Name] = len(combined)
+		combined = append(combined, t)
+	}
+	kept := combined[:0]
+	for _, t := range combined {
+		if t.Coefficient != 0 {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+// Constraint Look up a constraint by name, returning nil if there is no such constraint
+func (lp *LinearProgram) Constraint(name string) *LpConstraint {
+	for _, c := range lp.Constraints {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// SetRHS Change the right hand side of the named constraint. A range constraint keeps its width, so both of its bounds
+// move.
+func (lp *LinearProgram) SetRHS(name string, rightHandSide float64) *LinearProgram {
+	lp.mustConstraint(name).RightHandSide = rightHandSide
+	return lp
+}
+
+// SetCoefficient Change the coefficient of a variable in the named constraint, a zero coefficient removes the term
+func (lp *LinearProgram) SetCoefficient(name string, variable LpVariable, coefficient float64) *LinearProgram {
+	c := lp.mustConstraint(name)
+	if coefficient != 0 {
+		lp.registerVariable(variable)
+	}
+	for i, t := range c.Terms {
+		if t.Variable.Name != variable.Name {
+			continue
+		}
+		if coefficient == 0 {
+			c.Terms = append(c.Terms[:i:i], c.Terms[i+1:]...)
+		} else {
+			c.Terms[i].Coefficient = coefficient
+		}
+		return lp
+	}
+
+	if coefficient != 0 {
+		c.Terms = append(c.Terms, NewTerm(coefficient, variable))
+	}
+	return lp
+}
+
+// SetElastic Let the named constraint be violated at a cost of penalty per unit of violation. The solver adds a
+// non-negative surplus variable for each way the constraint can be broken, and charges the penalty against the
+// objective, so the solve finds the least costly violation instead of reporting the problem infeasible.
+// Result.Violation gives the amount the constraint was violated by.
+func (lp *LinearProgram) SetElastic(name string, penalty float64) *LinearProgram {
+	if penalty < 0 || math.IsNaN(penalty) || math.IsInf(penalty, 0) {
+		panic(fmt.Sprintf("Constraint %q: penalty must be finite and non-negative, got %v", name, penalty))
+	}
+	c := lp.mustConstraint(name)
+	if c.Range != 0 {
+		panic(fmt.Sprintf("Constraint %q: range constraints cannot be elastic", name))
+	}
+	c.Elastic, c.Penalty = true, penalty
+	return lp
+}
+
+// RemoveConstraint Remove the named constraint from the linear program
+func (lp *LinearProgram) RemoveConstraint(name string) *LinearProgram {
+	for i, c := range lp.Constraints {
+		if c.Name == name {
+			lp.Constraints = append(lp.Constraints[:i:i], lp.Constraints[i+1:]...)
+			return lp
+		}
+	}
+	panic(fmt.Sprintf("Constraint %q does not exist", name))
+}
+
+func (lp *LinearProgram) mustConstraint(name string) *LpConstraint {
+	c := lp.Constraint(name)
+	if c == nil {
+		panic(fmt.Sprintf("Constraint %q does not exist", name))
+	}
+	return c
+}
+
+// nextConstraintName Generate an unused name of the form c1, c2, ...
+func (lp *LinearProgram) nextConstraintName() string {
+	for i := len(lp.Constraints) + 1; ; i++ {
+		name := fmt.Sprintf("c%d", i)
+		if lp.Constraint(name) == nil {
+			return name
+		}
+	}
+}
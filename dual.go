@@ -0,0 +1,133 @@
+package gulp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrDualityGap Returned by CheckStrongDuality when the primal and dual results do not agree
+var ErrDualityGap = errors.New("primal and dual results do not agree")
+
+// Dual Build the dual of the linear program. Each constraint gives a dual variable named y_ and the constraint name,
+// and each variable gives a dual constraint of the same name. Every variable in gulp is non-negative, so a dual
+// variable that should be non-positive stands for the negative of the dual value, and the free dual variable of an
+// equality is split into y_name+ minus y_name-. A range constraint has a dual variable for each end, y_name_upper and
+// y_name_lower. The dual of an elastic constraint is bounded by its penalty. Integer categories, special ordered sets
+// and secondary objectives are ignored. Piecewise costs are expanded into their segments first, see AddPiecewiseCost.
+func (lp *LinearProgram) Dual() *LinearProgram {
+	if len(lp.Constraints) == 0 {
+		panic("Linear program has no constraints to take the dual of")
+	}
+	if len(lp.PiecewiseCosts) > 0 {
+		return lp.piecewiseModel().Dual()
+	}
+	sense := float64(lp.Sense)
+
+	// A dual variable is non-negative when its constraint is a <= in a maximisation or a >= in a minimisation
+	natural := LpConstraintLE
+	if lp.Sense == LpMinimise {
+		natural = LpConstraintGE
+	}
+
+	used := make(map[string]bool)
+	name := func(name string) LpVariable {
+		for used[name] {
+			name += "'"
+		}
+		used[name] = true
+		return NewVariable(name)
+	}
+
+	// columns The dual variables of each constraint, with the sign each takes in the dual value
+	columns := make([][]LpTerm, len(lp.Constraints))
+	var objective []LpTerm
+	for i, c := range lp.Constraints {
+		if c.Range != 0 {
+			// Each end of a range is an inequality of its own
+			upper, lower := 1.0, -1.0
+			if natural == LpConstraintGE {
+				upper, lower = -1, 1
+			}
+			columns[i] = []LpTerm{NewTerm(upper, name("y_"+c.Name+"_upper")), NewTerm(lower, name("y_"+c.Name+"_lower"))}
+			objective = append(objective, NewTerm(upper*c.RightHandSide, columns[i][0].Variable), NewTerm(lower*c.lowerBound(), columns[i][1].Variable))
+			continue
+		}
+		switch c.ConstraintType {
+		case natural:
+			columns[i] = []LpTerm{NewTerm(1, name("y_"+c.Name))}
+		case -natural:
+			columns[i] = []LpTerm{NewTerm(-1, name("y_"+c.Name))}
+		default:
+			columns[i] = []LpTerm{NewTerm(1, name("y_"+c.Name+"+")), NewTerm(-1, name("y_"+c.Name+"-"))}
+		}
+		for _, t := range columns[i] {
+			objective = append(objective, NewTerm(t.Coefficient*c.RightHandSide, t.Variable))
+		}
+	}
+
+	dual := NewLinearProgram()
+	dual.AddObjective(LpSense(-sense), NewExpression(objective))
+
+	// A maximisation has dual constraints A^T y >= c, a minimisation A^T y <= c
+	constraintType := LpConstraintType(sense)
+	costs := make([]float64, len(lp.variables))
+	for _, t := range lp.userObjective() {
+		costs[lp.variableIndex[t.Variable.Name]] += t.Coefficient
+	}
+	rows := lp.sparseRows()
+	for j, v := range lp.variables {
+		var terms []LpTerm
+		for i, row := range rows {
+			if a, ok := row.coefficients[j]; ok {
+				for _, t := range columns[i] {
+					terms = append(terms, NewTerm(a*t.Coefficient, t.Variable))
+				}
+			}
+		}
+		dual.AddNamedConstraint(v.Name, NewExpression(terms), constraintType, costs[j])
+	}
+
+	// The surplus and deficit of an elastic constraint are columns with coefficient -1 and +1, each costing the penalty
+	for i, c := range lp.Constraints {
+		if !c.Elastic {
+			continue
+		}
+		if c.ConstraintType != LpConstraintGE {
+			dual.AddNamedConstraint(c.Name+" surplus", NewExpression(scaleTerms(columns[i], -1)), constraintType, -sense*c.Penalty)
+		}
+		if c.ConstraintType != LpConstraintLE {
+			dual.AddNamedConstraint(c.Name+" deficit", NewExpression(scaleTerms(columns[i], 1)), constraintType, -sense*c.Penalty)
+		}
+	}
+
+	return &dual
+}
+
+// CheckStrongDuality Check that the results of solving a linear program and its Dual agree: both optimal with the same
+// objective value within the default tolerance, relative to the size of the value, or one unbounded and the other
+// infeasible. Returns an error wrapping ErrDualityGap if they do not.
+func CheckStrongDuality(primal, dual *Result) error {
+	switch {
+	case primal.Status() == LpStatusOptimal && dual.Status() == LpStatusOptimal:
+		gap := math.Abs(primal.ObjectiveValue() - dual.ObjectiveValue())
+		if gap > DefaultTolerance*math.Max(1, math.Abs(primal.ObjectiveValue())) {
+			return fmt.Errorf("%w: primal objective %v, dual objective %v", ErrDualityGap, formatNumber(primal.ObjectiveValue()), formatNumber(dual.ObjectiveValue()))
+		}
+		return nil
+	case primal.Status() == LpStatusUnbounded && dual.Status() == LpStatusInfeasible,
+		primal.Status() == LpStatusInfeasible && dual.Status() == LpStatusUnbounded,
+		primal.Status() == LpStatusInfeasible && dual.Status() == LpStatusInfeasible:
+		return nil
+	}
+	return fmt.Errorf("%w: primal is %v, dual is %v", ErrDualityGap, primal.Status(), dual.Status())
+}
+
+// scaleTerms Copy the terms with each coefficient multiplied by scale
+func scaleTerms(terms []LpTerm, scale float64) []LpTerm {
+	scaled := make([]LpTerm, len(terms))
+	for i, t := range terms {
+		scaled[i] = NewTerm(scale*t.Coefficient, t.Variable)
+	}
+	return scaled
+}
@@ -0,0 +1,139 @@
+package gulp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LaTeX Render the linear program as a LaTeX align* block with the objective, the constraints and the non-negativity
+// restrictions. Constraints are tagged with their names.
+func (lp *LinearProgram) LaTeX() string {
+	sb := strings.Builder{}
+	sb.WriteString("\\begin{align*}\n")
+	sb.WriteString(fmt.Sprintf("\\text{%v} \\quad & %v \\\\\n", strings.ToLower(senseName(lp.Sense)), formatTerms(lp.userObjective(), latexTerm)))
+
+	for i, c := range lp.Constraints {
+		if i == 0 {
+			sb.WriteString("\\text{subject to} \\quad ")
+		}
+		if c.Range != 0 {
+			sb.WriteString(fmt.Sprintf("& %v \\leq %v \\leq %v \\tag{%v} \\\\\n",
+				formatNumber(c.lowerBound()), formatTerms(c.Terms, latexTerm), formatNumber(c.RightHandSide), latexEscape(c.Name)))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("& %v %v %v \\tag{%v} \\\\\n",
+			formatTerms(c.Terms, latexTerm), latexRelation(c.ConstraintType), formatNumber(c.RightHandSide), latexEscape(c.Name)))
+	}
+
+	names := make([]string, len(lp.variables))
+	for i, v := range lp.variables {
+		names[i] = latexMathName(v.Name)
+	}
+	sb.WriteString(fmt.Sprintf("& %v \\geq 0\n", strings.Join(names, ", ")))
+	sb.WriteString("\\end{align*}\n")
+	return sb.String()
+}
+
+// Markdown Render the linear program as Markdown, with the constraints in a table
+func (lp *LinearProgram) Markdown() string {
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("**%v** %v\n\n", senseName(lp.Sense), formatTerms(lp.userObjective(), markdownTerm)))
+
+	if len(lp.Constraints) > 0 {
+		sb.WriteString("**Subject to**\n\n")
+		sb.WriteString("| Constraint | Expression | | RHS |\n")
+		sb.WriteString("| --- | --- | :---: | ---: |\n")
+		for _, c := range lp.Constraints {
+			relation, rightHandSide := markdownRelation(c.ConstraintType), formatNumber(c.RightHandSide)
+			if c.Range != 0 {
+				relation, rightHandSide = "∈", fmt.Sprintf("[%v, %v]", formatNumber(c.lowerBound()), rightHandSide)
+			}
+			sb.WriteString(fmt.Sprintf("| %v | %v | %v | %v |\n",
+				escapeMarkdown(c.Name), formatTerms(c.Terms, markdownTerm), relation, rightHandSide))
+		}
+		sb.WriteString("\n")
+	}
+
+	names := make([]string, len(lp.variables))
+	for i, v := range lp.variables {
+		names[i] = escapeMarkdown(v.Name)
+	}
+	sb.WriteString(strings.Join(names, ", ") + " ≥ 0\n")
+	return sb.String()
+}
+
+// LaTeX Render the tableau as a LaTeX tabular, showing values as fractions when fractions is set
+func (t *Tableau) LaTeX(fractions bool) string {
+	return t.latex(fractions, -1, -1)
+}
+
+// Markdown Render the tableau as a Markdown table, showing values as fractions when fractions is set
+func (t *Tableau) Markdown(fractions bool) string {
+	grid := t.grid(fractions)
+
+	sb := strings.Builder{}
+	row := func(cells []string, bold bool) {
+		for i, cell := range cells {
+			cell = escapeMarkdown(cell)
+			if bold && i == 0 {
+				cell = "**" + cell + "**"
+			}
+			sb.WriteString("| " + cell + " ")
+		}
+		sb.WriteString("|\n")
+	}
+
+	row(grid.Header, false)
+	sb.WriteString("| --- | ---:" + strings.Repeat(" | ---:", len(t.NamesRow)+1) + " |\n")
+	row(grid.Costs, true)
+	for _, r := range grid.Rows {
+		row(r, false)
+	}
+	row(grid.Z, true)
+	row(grid.CZ, true)
+	return sb.String()
+}
+
+// senseName Get the word for the optimisation sense
+func senseName(sense LpSense) string {
+	if sense == LpMinimise {
+		return "Minimise"
+	}
+	return "Maximise"
+}
+
+// latexTerm Format a term as "6x_{1}", leaving out a coefficient of one
+func latexTerm(coefficient float64, name string) string {
+	if coefficient == 1 {
+		return latexMathName(name)
+	}
+	return formatNumber(coefficient) + latexMathName(name)
+}
+
+// markdownTerm Format a term as "6 x1", leaving out a coefficient of one
+func markdownTerm(coefficient float64, name string) string {
+	if coefficient == 1 {
+		return escapeMarkdown(name)
+	}
+	return formatNumber(coefficient) + " " + escapeMarkdown(name)
+}
+
+func latexRelation(constraintType LpConstraintType) string {
+	switch constraintType {
+	case LpConstraintLE:
+		return "\\leq"
+	case LpConstraintGE:
+		return "\\geq"
+	}
+	return "="
+}
+
+func markdownRelation(constraintType LpConstraintType) string {
+	switch constraintType {
+	case LpConstraintLE:
+		return "≤"
+	case LpConstraintGE:
+		return "≥"
+	}
+	return "="
+}
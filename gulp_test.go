@@ -1,8 +1,12 @@
 package gulp
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
+	"math/big"
+	"strings"
 	"testing"
 )
 
@@ -69,13 +73,9 @@ LinearProgram
 ********************************************************************************************************************* */
 
 func TestNewLinearProgram(t *testing.T) {
-	expected := LinearProgram{
-		Status: LpStatusNotSolved,
-	}
-
 	lp := NewLinearProgram()
-	if lp.Status != expected.Status {
-		t.Errorf("Expected %v, got %v", expected, lp)
+	if len(lp.Constraints) != 0 || len(lp.Variables()) != 0 || len(lp.ObjectiveFunction.Terms) != 0 {
+		t.Errorf("Expected an empty linear program, got %v", lp)
 	}
 }
 
@@ -102,6 +102,34 @@ func TestAddObjective(t *testing.T) {
 	}
 }
 
+func TestAddObjectiveKeepsInput(t *testing.T) {
+	x, y := NewVariable("x"), NewVariable("y")
+	objective := NewExpression([]LpTerm{NewTerm(3, x), NewTerm(-2, y)})
+	constraint := NewExpression([]LpTerm{NewTerm(1, x), NewTerm(-1, y)})
+
+	lp := NewLinearProgram()
+	lp.AddObjective(LpMinimise, objective).
+		AddConstraint(constraint, LpConstraintGE, -4)
+	lp.Solve()
+
+	if objective.Terms[0].Coefficient != 3 || constraint.Terms[1].Coefficient != -1 {
+		t.Errorf("Expected the expressions to be left unchanged, got %v and %v", objective, constraint)
+	}
+	if lp.Sense != LpMinimise || lp.ObjectiveFunction.Terms[0].Coefficient != 3 {
+		t.Errorf("Expected the objective as written, got %v %v", lp.Sense, lp.ObjectiveFunction)
+	}
+	expected := "Min: 3 * x - 2 * y\n\t1 * x - 1 * y >= -4"
+	if lp.String() != expected {
+		t.Errorf("Expected\n%v\ngot\n%v", expected, lp.String())
+	}
+
+	// Editing the model does not reach back into the expressions it was built from
+	lp.SetCoefficient("c1", x, 5)
+	if constraint.Terms[0].Coefficient != 1 {
+		t.Errorf("Expected the constraint expression to be left unchanged, got %v", constraint)
+	}
+}
+
 func TestAddConstraint(t *testing.T) {
 	fmt.Println("TestAddConstraint")
 }
@@ -155,23 +183,23 @@ func TestSolve(t *testing.T) {
 	}
 
 	lp := NewLinearProgram()
-	lp.AddObjective(LpMaximise, objective).
+	result := lp.AddObjective(LpMaximise, objective).
 		AddConstraint(NewExpression(terms2), LpConstraintLE, 16).
 		AddConstraint(NewExpression(terms3), LpConstraintLE, 12).
 		Solve()
 
-	if lp.hiddenSense != expectedSense {
-		t.Errorf("Expected %v, got %v", expectedSense, lp.hiddenSense)
+	if lp.Sense != expectedSense {
+		t.Errorf("Expected %v, got %v", expectedSense, lp.Sense)
 	}
-	if lp.Status != expectedStatus {
-		t.Errorf("Expected %v, got %v", expectedStatus, lp.Status)
+	if result.Status() != expectedStatus {
+		t.Errorf("Expected %v, got %v", expectedStatus, result.Status())
 	}
-	if lp.OptimalValue != expectedOptimalValue {
-		t.Errorf("Expected %v, got %v", expectedOptimalValue, lp.OptimalValue)
+	if result.ObjectiveValue() != expectedOptimalValue {
+		t.Errorf("Expected %v, got %v", expectedOptimalValue, result.ObjectiveValue())
 	}
-	for k, v := range lp.Solution {
+	for k, v := range result.Values() {
 		if math.Abs(v-expectedSolution[k]) > 0.0001 {
-			t.Errorf("Expected %v, got %v", expectedSolution, lp.Solution)
+			t.Errorf("Expected %v, got %v", expectedSolution, result.Values())
 		}
 	}
 }
@@ -222,29 +250,2078 @@ func TestSolveMinimum(t *testing.T) {
 	}
 
 	lp := NewLinearProgram()
-	lp.AddObjective(LpMinimise, objective).
+	result := lp.AddObjective(LpMinimise, objective).
 		AddConstraint(NewExpression(terms2), LpConstraintLE, 18).
 		AddConstraint(NewExpression(terms3), LpConstraintLE, -14).
 		AddConstraint(NewExpression(terms4), LpConstraintEQ, 26).
 		Solve()
 
-	if lp.hiddenSense != expectedSense {
-		t.Errorf("Expected %v, got %v", expectedSense, lp.hiddenSense)
+	if lp.Sense != expectedSense {
+		t.Errorf("Expected %v, got %v", expectedSense, lp.Sense)
 	}
 
-	if lp.Status != expectedStatus {
-		t.Errorf("Expected %v, got %v", expectedStatus, lp.Status)
+	if result.Status() != expectedStatus {
+		t.Errorf("Expected %v, got %v", expectedStatus, result.Status())
 	}
 
-	if lp.OptimalValue != expectedOptimalValue {
-		t.Errorf("Expected %v, got %v", expectedOptimalValue, lp.OptimalValue)
+	if result.ObjectiveValue() != expectedOptimalValue {
+		t.Errorf("Expected %v, got %v", expectedOptimalValue, result.ObjectiveValue())
 	}
 
-	for k, v := range lp.Solution {
+	for k, v := range result.Values() {
 		if math.Abs(v-expectedSolution[k]) > 0.0001 {
-			t.Errorf("Expected %v, got %v", expectedSolution, lp.Solution)
+			t.Errorf("Expected %v, got %v", expectedSolution, result.Values())
+		}
+	}
+}
+
+/* *********************************************************************************************************************
+Variable Registry
+********************************************************************************************************************* */
+
+func TestAddVariable(t *testing.T) {
+	x := NewVariable("x")
+	y := NewVariable("y")
+	z := NewVariable("z")
+
+	lp := NewLinearProgram()
+	lp.AddVariable(z)
+	lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(1, x), NewTerm(1, y)}))
+	lp.AddVariable(x, y)
+
+	expected := []LpVariable{z, x, y}
+	result := lp.Variables()
+	if len(result) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, result)
+	}
+	for i, v := range result {
+		if v != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, result)
+		}
+		if lp.VariableIndex(v.Name) != i {
+			t.Errorf("Expected index %v for %v, got %v", i, v.Name, lp.VariableIndex(v.Name))
+		}
+	}
+
+	if v, ok := lp.Variable("y"); !ok || v != y {
+		t.Errorf("Expected %v, got %v", y, v)
+	}
+	if _, ok := lp.Variable("w"); ok {
+		t.Errorf("Expected no variable named w")
+	}
+	if lp.VariableIndex("w") != -1 {
+		t.Errorf("Expected %v, got %v", -1, lp.VariableIndex("w"))
+	}
+}
+
+func TestAddVariableRejected(t *testing.T) {
+	cases := map[string]LpVariable{
+		"conflict": {Name: "x", Value: 3},
+		"slack":    NewVariable("s1"),
+		"artifact": NewVariable("a12"),
+		"empty":    NewVariable(""),
+		"isSlack":  NewSlackVariable("slack"),
+	}
+
+	for name, v := range cases {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("Expected panic, got nil")
+				}
+			}()
+
+			lp := NewLinearProgram()
+			lp.AddVariable(NewVariable("x"))
+			lp.AddVariable(v)
+		})
+	}
+}
+
+func TestConstraintOnlyVariable(t *testing.T) {
+	// Maximise x subject to x - y <= 2, y <= 3
+	x := NewVariable("x")
+	y := NewVariable("y")
+
+	lp := NewLinearProgram()
+	result := lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(1, x)})).
+		AddConstraint(NewExpression([]LpTerm{NewTerm(1, x), NewTerm(-1, y)}), LpConstraintLE, 2).
+		AddConstraint(NewExpression([]LpTerm{NewTerm(1, y)}), LpConstraintLE, 3).
+		Solve()
+
+	if math.Abs(result.ObjectiveValue()-5) > 0.0001 {
+		t.Errorf("Expected %v, got %v", 5, result.ObjectiveValue())
+	}
+	if math.Abs(result.Value(y)-3) > 0.0001 {
+		t.Errorf("Expected %v, got %v", 3, result.Value(y))
+	}
+}
+
+/* *********************************************************************************************************************
+Constraints
+********************************************************************************************************************* */
+
+func TestAddNamedConstraint(t *testing.T) {
+	apples := NewVariable("Apples")
+	bananas := NewVariable("Bananas")
+
+	lp := NewLinearProgram()
+	lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(7, apples), NewTerm(6, bananas)}))
+	lp.AddConstraint(NewExpression([]LpTerm{NewTerm(2, apples), NewTerm(4, bananas)}), LpConstraintLE, 16)
+	land := lp.AddNamedConstraint("land", NewExpression([]LpTerm{NewTerm(3, apples), NewTerm(2, bananas)}), LpConstraintLE, 12)
+
+	if land.Name != "land" {
+		t.Errorf("Expected %v, got %v", "land", land.Name)
+	}
+	if lp.Constraint("land") != land {
+		t.Errorf("Expected lookup to return the added constraint")
+	}
+	if lp.Constraint("c1") == nil {
+		t.Errorf("Expected automatically named constraint c1")
+	}
+	if lp.Constraint("missing") != nil {
+		t.Errorf("Expected nil for a missing constraint")
+	}
+}
+
+func TestAddNamedConstraintDuplicate(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected panic, got nil")
+		}
+	}()
+
+	x := NewVariable("x")
+	lp := NewLinearProgram()
+	lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(1, x)}))
+	lp.AddNamedConstraint("cap", NewExpression([]LpTerm{NewTerm(1, x)}), LpConstraintLE, 1)
+	lp.AddNamedConstraint("cap", NewExpression([]LpTerm{NewTerm(1, x)}), LpConstraintLE, 2)
+}
+
+func TestModifyConstraints(t *testing.T) {
+	apples := NewVariable("Apples")
+	bananas := NewVariable("Bananas")
+
+	lp := NewLinearProgram()
+	lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(7, apples), NewTerm(6, bananas)}))
+	lp.AddNamedConstraint("water", NewExpression([]LpTerm{NewTerm(2, apples), NewTerm(4, bananas)}), LpConstraintLE, 16)
+	lp.AddNamedConstraint("land", NewExpression([]LpTerm{NewTerm(3, apples), NewTerm(2, bananas)}), LpConstraintLE, 12)
+	lp.AddNamedConstraint("limit", NewExpression([]LpTerm{NewTerm(1, apples)}), LpConstraintLE, 1)
+
+	result := lp.Solve()
+	if math.Abs(result.ObjectiveValue()-28) > 0.0001 {
+		t.Errorf("Expected %v, got %v", 28, result.ObjectiveValue())
+	}
+	if math.Abs(result.Slack("land")-2) > 0.0001 {
+		t.Errorf("Expected %v, got %v", 2, result.Slack("land"))
+	}
+
+	lp.RemoveConstraint("limit").SetRHS("water", 20).SetCoefficient("land", bananas, 3)
+	if len(lp.Constraints) != 2 {
+		t.Fatalf("Expected %v constraints, got %v", 2, len(lp.Constraints))
+	}
+
+	// Maximise 7 * Apples + 6 * Bananas subject to 2 * Apples + 4 * Bananas <= 20 and 3 * Apples + 3 * Bananas <= 12
+	result = lp.Solve()
+	if math.Abs(result.ObjectiveValue()-28) > 0.0001 {
+		t.Errorf("Expected %v, got %v", 28, result.ObjectiveValue())
+	}
+	if math.Abs(result.Slack("water")-12) > 0.0001 {
+		t.Errorf("Expected %v, got %v", 12, result.Slack("water"))
+	}
+}
+
+/* *********************************************************************************************************************
+Result
+********************************************************************************************************************* */
+
+// newExampleProgram Build the minimisation example from the README
+func newExampleProgram() (*LinearProgram, []LpVariable) {
+	x := []LpVariable{NewVariable("x1"), NewVariable("x2"), NewVariable("x3")}
+
+	lp := NewLinearProgram()
+	lp.AddObjective(LpMinimise, NewExpression([]LpTerm{NewTerm(-6, x[0]), NewTerm(7, x[1]), NewTerm(4, x[2])}))
+	lp.AddNamedConstraint("first", NewExpression([]LpTerm{NewTerm(2, x[0]), NewTerm(5, x[1]), NewTerm(-1, x[2])}), LpConstraintLE, 18)
+	lp.AddNamedConstraint("second", NewExpression([]LpTerm{NewTerm(1, x[0]), NewTerm(-1, x[1]), NewTerm(-2, x[2])}), LpConstraintLE, -14)
+	lp.AddNamedConstraint("third", NewExpression([]LpTerm{NewTerm(3, x[0]), NewTerm(2, x[1]), NewTerm(2, x[2])}), LpConstraintEQ, 26)
+	return &lp, x
+}
+
+func TestResultSensitivity(t *testing.T) {
+	lp, x := newExampleProgram()
+	result := lp.Solve()
+
+	expectedDuals := map[string]float64{"first": 0, "second": -3, "third": -1}
+	for name, expected := range expectedDuals {
+		if math.Abs(result.Dual(name)-expected) > 0.0001 {
+			t.Errorf("Expected dual %v for %v, got %v", expected, name, result.Dual(name))
+		}
+	}
+
+	expectedReducedCosts := []float64{0, 6, 0}
+	for i, v := range x {
+		if math.Abs(result.ReducedCost(v)-expectedReducedCosts[i]) > 0.0001 {
+			t.Errorf("Expected reduced cost %v for %v, got %v", expectedReducedCosts[i], v.Name, result.ReducedCost(v))
+		}
+	}
+
+	if math.Abs(result.Slack("first")-20.5) > 0.0001 {
+		t.Errorf("Expected %v, got %v", 20.5, result.Slack("first"))
+	}
+	if math.Abs(result.Activity("third")-26) > 0.0001 {
+		t.Errorf("Expected %v, got %v", 26, result.Activity("third"))
+	}
+	if result.Iterations() == 0 {
+		t.Errorf("Expected at least one iteration")
+	}
+}
+
+func TestResultIsSnapshot(t *testing.T) {
+	lp, x := newExampleProgram()
+	first := lp.Solve()
+
+	lp.SetRHS("third", 30)
+	second := lp.Solve()
+
+	if first.ObjectiveValue() == second.ObjectiveValue() {
+		t.Errorf("Expected different objective values, got %v twice", first.ObjectiveValue())
+	}
+	if math.Abs(first.Value(x[0])-3) > 0.0001 {
+		t.Errorf("Expected %v, got %v", 3, first.Value(x[0]))
+	}
+}
+
+func TestSolveConcurrently(t *testing.T) {
+	lp, _ := newExampleProgram()
+
+	results := make(chan *Result)
+	for i := 0; i < 8; i++ {
+		go func() {
+			results <- lp.Solve()
+		}()
+	}
+	for i := 0; i < 8; i++ {
+		if result := <-results; result.ObjectiveValue() != 16 {
+			t.Errorf("Expected %v, got %v", 16, result.ObjectiveValue())
+		}
+	}
+}
+
+func TestSolveInfeasible(t *testing.T) {
+	// x <= 1 and x >= 2
+	x := NewVariable("x")
+	lp := NewLinearProgram()
+	result := lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(1, x)})).
+		AddConstraint(NewExpression([]LpTerm{NewTerm(1, x)}), LpConstraintLE, 1).
+		AddConstraint(NewExpression([]LpTerm{NewTerm(1, x)}), LpConstraintGE, 2).
+		Solve()
+
+	if result.Status() != LpStatusInfeasible {
+		t.Errorf("Expected %v, got %v", LpStatusInfeasible, result.Status())
+	}
+}
+
+func TestSolveUnbounded(t *testing.T) {
+	// Maximise x + y subject to x - y <= 1
+	x := NewVariable("x")
+	y := NewVariable("y")
+	lp := NewLinearProgram()
+	result := lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(1, x), NewTerm(1, y)})).
+		AddConstraint(NewExpression([]LpTerm{NewTerm(1, x), NewTerm(-1, y)}), LpConstraintLE, 1).
+		Solve()
+
+	if result.Status() != LpStatusUnbounded {
+		t.Errorf("Expected %v, got %v", LpStatusUnbounded, result.Status())
+	}
+	if !math.IsInf(result.ObjectiveValue(), 1) {
+		t.Errorf("Expected +Inf, got %v", result.ObjectiveValue())
+	}
+}
+
+func TestSolveInfeasibleRay(t *testing.T) {
+	// x1 can rise forever, but x2 <= -1 cannot be met, so the ray is not one of the problem
+	x1, x2 := NewVariable("x1"), NewVariable("x2")
+	lp := NewLinearProgram()
+	lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(1, x1)})).
+		AddConstraint(NewExpression([]LpTerm{NewTerm(1, x2)}), LpConstraintLE, -1)
+	for _, options := range [][]SolverOption{nil, {WithExactArithmetic()}} {
+		if result := lp.Solve(options...); result.Status() != LpStatusInfeasible {
+			t.Errorf("Expected %v, got %v", LpStatusInfeasible, result.Status())
+		}
+	}
+
+	// With x2 >= 1 instead the constraints can be met, and the ray is real
+	lp.SetRHS("c1", 1).Constraint("c1").ConstraintType = LpConstraintGE
+	for _, options := range [][]SolverOption{nil, {WithExactArithmetic()}} {
+		if result := lp.Solve(options...); result.Status() != LpStatusUnbounded {
+			t.Errorf("Expected %v, got %v", LpStatusUnbounded, result.Status())
+		}
+	}
+}
+
+func TestSolveDegenerateEquality(t *testing.T) {
+	// The second row repeats the first, so one artificial variable stays in the basis at zero
+	x, y := NewVariable("x"), NewVariable("y")
+	lp := NewLinearProgram()
+	lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(1, x), NewTerm(2, y)}))
+	lp.AddNamedConstraint("once", NewExpression([]LpTerm{NewTerm(1, x), NewTerm(1, y)}), LpConstraintEQ, 4)
+	lp.AddNamedConstraint("twice", NewExpression([]LpTerm{NewTerm(2, x), NewTerm(2, y)}), LpConstraintEQ, 8)
+	result := lp.Solve()
+	if result.Status() != LpStatusOptimal || result.ObjectiveValue() != 8 {
+		t.Fatalf("Expected 8, got %v %v", result.Status(), result.ObjectiveValue())
+	}
+	if result.Dual("once") != 2 || result.Dual("twice") != 0 || result.ReducedCost(x) != -1 || result.ReducedCost(y) != 0 {
+		t.Errorf("Expected duals 2 and 0 and reduced costs -1 and 0, got %v %v %v %v", result.Dual("once"), result.Dual("twice"), result.ReducedCost(x), result.ReducedCost(y))
+	}
+}
+
+func TestSolveCycling(t *testing.T) {
+	// Beale's example, on which the largest coefficient rule cycles through degenerate pivots forever
+	x4, x5, x6, x7 := NewVariable("x4"), NewVariable("x5"), NewVariable("x6"), NewVariable("x7")
+	lp := NewLinearProgram()
+	lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(0.75, x4), NewTerm(-150, x5), NewTerm(0.02, x6), NewTerm(-6, x7)}))
+	lp.AddConstraint(NewExpression([]LpTerm{NewTerm(0.25, x4), NewTerm(-60, x5), NewTerm(-0.04, x6), NewTerm(9, x7)}), LpConstraintLE, 0)
+	lp.AddConstraint(NewExpression([]LpTerm{NewTerm(0.5, x4), NewTerm(-90, x5), NewTerm(-0.02, x6), NewTerm(3, x7)}), LpConstraintLE, 0)
+	lp.AddConstraint(NewExpression([]LpTerm{NewTerm(1, x6)}), LpConstraintLE, 1)
+	result := lp.Solve()
+	if result.Status() != LpStatusOptimal || math.Abs(result.ObjectiveValue()-0.05) > 1e-9 {
+		t.Errorf("Expected 0.05, got %v %v after %v iterations", result.Status(), result.ObjectiveValue(), result.Iterations())
+	}
+	if result.Iterations() > 2*cyclingLimit {
+		t.Errorf("Expected Bland's rule to end the cycle, took %v iterations", result.Iterations())
+	}
+
+	// Round-off against big M hides the progress of each pivot, but the constraints cannot be met
+	x := []LpVariable{NewVariable("x0"), NewVariable("x1"), NewVariable("x2"), NewVariable("x3")}
+	lp = NewLinearProgram()
+	lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(1, x[0]), NewTerm(-1, x[1]), NewTerm(3, x[2]), NewTerm(3, x[3])}))
+	lp.AddConstraint(NewExpression([]LpTerm{NewTerm(-2, x[0]), NewTerm(-1, x[1]), NewTerm(2, x[2])}), LpConstraintGE, 2)
+	lp.AddConstraint(NewExpression([]LpTerm{NewTerm(2, x[0]), NewTerm(1, x[1]), NewTerm(2, x[2]), NewTerm(1, x[3])}), LpConstraintEQ, 1)
+	lp.AddConstraint(NewExpression([]LpTerm{NewTerm(-1, x[1]), NewTerm(-2, x[2]), NewTerm(2, x[3])}), LpConstraintGE, 2)
+	lp.AddConstraint(NewExpression([]LpTerm{NewTerm(2, x[0]), NewTerm(-2, x[1]), NewTerm(2, x[3])}), LpConstraintLE, -1)
+	if result := lp.Solve(); result.Status() != LpStatusInfeasible {
+		t.Errorf("Expected %v, got %v after %v iterations", LpStatusInfeasible, result.Status(), result.Iterations())
+	}
+}
+
+/* *********************************************************************************************************************
+Reports
+********************************************************************************************************************* */
+
+func TestWriteReport(t *testing.T) {
+	lp, _ := newExampleProgram()
+	result := lp.Solve()
+
+	expected := map[ReportFormat]string{
+		ReportText: `Status:     Optimal
+Objective:  16
+
+Variable  Value  Reduced Cost
+x1        3      0
+x2        0      6
+x3        8.5    0
+
+Constraint  Activity  Slack  Dual
+first       -2.5      20.5   0
+second      -14       0      -3
+third       26        0      -1
+`,
+		ReportCSV: `type,name,value,reduced_cost,activity,slack,dual
+status,Optimal,,,,,
+objective,,16,,,,
+variable,x1,3,0,,,
+variable,x2,0,6,,,
+variable,x3,8.5,0,,,
+constraint,first,,,-2.5,20.5,0
+constraint,second,,,-14,0,-3
+constraint,third,,,26,0,-1
+`,
+		ReportMarkdown: `**Status:** Optimal
+
+**Objective:** 16
+
+| Variable | Value | Reduced Cost |
+| --- | ---: | ---: |
+| x1 | 3 | 0 |
+| x2 | 0 | 6 |
+| x3 | 8.5 | 0 |
+
+| Constraint | Activity | Slack | Dual |
+| --- | ---: | ---: | ---: |
+| first | -2.5 | 20.5 | 0 |
+| second | -14 | 0 | -3 |
+| third | 26 | 0 | -1 |
+`,
+	}
+
+	for format, want := range expected {
+		sb := strings.Builder{}
+		if err := result.WriteReport(&sb, format); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if sb.String() != want {
+			t.Errorf("Format %v: expected\n%v\ngot\n%v", format, want, sb.String())
+		}
+	}
+
+	if err := result.WriteReport(&strings.Builder{}, ReportFormat(99)); err == nil {
+		t.Errorf("Expected an error for an unknown format")
+	}
+}
+
+/* *********************************************************************************************************************
+Tableau
+********************************************************************************************************************* */
+
+// newApplesProgram Build the maximisation example used by TestSolve
+func newApplesProgram() (*LinearProgram, []LpVariable) {
+	x := []LpVariable{NewVariable("Apples"), NewVariable("Bananas")}
+
+	lp := NewLinearProgram()
+	lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(7, x[0]), NewTerm(6, x[1])}))
+	lp.AddNamedConstraint("water", NewExpression([]LpTerm{NewTerm(2, x[0]), NewTerm(4, x[1])}), LpConstraintLE, 16)
+	lp.AddNamedConstraint("land", NewExpression([]LpTerm{NewTerm(3, x[0]), NewTerm(2, x[1])}), LpConstraintLE, 12)
+	return &lp, x
+}
+
+func TestTableauString(t *testing.T) {
+	lp, _ := newApplesProgram()
+	tableau := NewTableau(lp)
+
+	expected := `Basis | C_B | Apples | Bananas | s1 | s2 |  b
+C_j   |     |      7 |       6 |  0 |  0 |
+------+-----+--------+---------+----+----+---
+s1    |   0 |      2 |       4 |  1 |  0 | 16
+s2    |   0 |      3 |       2 |  0 |  1 | 12
+------+-----+--------+---------+----+----+---
+Z     |     |      0 |       0 |  0 |  0 |  0
+C-Z   |     |      7 |       6 |  0 |  0 |
+`
+	if tableau.String() != expected {
+		t.Errorf("Expected\n%v\ngot\n%v", expected, tableau.String())
+	}
+
+	tableau.Pivot()
+	expected = `Basis  | C_B | Apples | Bananas | s1 |   s2 |  b
+C_j    |     |      7 |       6 |  0 |    0 |
+-------+-----+--------+---------+----+------+---
+s1     |   0 |      0 |     8/3 |  1 | -2/3 |  8
+Apples |   7 |      1 |     2/3 |  0 |  1/3 |  4
+-------+-----+--------+---------+----+------+---
+Z      |     |      7 |    14/3 |  0 |  7/3 | 28
+C-Z    |     |      0 |     4/3 |  0 | -7/3 |
+`
+	if tableau.Format(true) != expected {
+		t.Errorf("Expected\n%v\ngot\n%v", expected, tableau.Format(true))
+	}
+}
+
+func TestTableauStringBigM(t *testing.T) {
+	lp, _ := newExampleProgram()
+	tableau := NewTableau(lp)
+
+	if !strings.Contains(tableau.String(), "a2    |  -M |") {
+		t.Errorf("Expected artificial costs to be shown as -M, got\n%v", tableau.String())
+	}
+}
+
+func TestPivotAt(t *testing.T) {
+	lp, _ := newApplesProgram()
+	tableau := NewTableau(lp)
+
+	if columns := tableau.CandidateColumns(); len(columns) != 2 || columns[0] != 0 || columns[1] != 1 {
+		t.Errorf("Expected candidate columns [0 1], got %v", columns)
+	}
+	if ratios := tableau.RatioTest(tableau.ColumnIndex("Apples")); ratios[0] != 8 || ratios[1] != 4 {
+		t.Errorf("Expected ratios [8 4], got %v", ratios)
+	}
+
+	rejected := map[error][2]int{
+		ErrPivotOutOfRange: {2, 0},
+		ErrPivotZero:       {1, 2},
+		ErrPivotInfeasible: {0, 0},
+	}
+	for expected, position := range rejected {
+		if err := tableau.PivotAt(position[0], position[1]); !errors.Is(err, expected) {
+			t.Errorf("Expected %v pivoting at %v, got %v", expected, position, err)
+		}
+	}
+	if tableau.TableauValue != 0 || tableau.BasisNames[0] != "s1" || tableau.BasisNames[1] != "s2" {
+		t.Errorf("Expected rejected pivots to leave the tableau unchanged\n%v", tableau)
+	}
+
+	if err := tableau.PivotAt(tableau.RowIndex("s2"), tableau.ColumnIndex("Apples")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := tableau.PivotAt(tableau.RowIndex("s1"), tableau.ColumnIndex("Bananas")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !tableau.IsOptimal() || len(tableau.CandidateColumns()) != 0 || math.Abs(tableau.TableauValue-32) > 1e-9 {
+		t.Errorf("Expected the optimal tableau\n%v", tableau)
+	}
+}
+
+func TestPivotAtBounds(t *testing.T) {
+	// The slack of 0 <= x - y <= 1 is bounded by 1
+	x, y := NewVariable("x"), NewVariable("y")
+	lp := NewLinearProgram()
+	lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(1, x), NewTerm(1, y)}))
+	lp.AddNamedRangeConstraint("c1", NewExpression([]LpTerm{NewTerm(1, x), NewTerm(-1, y)}), 0, 1)
+	lp.AddNamedConstraint("c2", NewExpression([]LpTerm{NewTerm(1, y)}), LpConstraintLE, 5)
+	tableau := NewTableau(&lp)
+
+	// Raising y lowers x - y, so s1 reaches its upper bound at once
+	if ratios := tableau.RatioTest(tableau.ColumnIndex("y")); ratios[0] != 0 || ratios[1] != 5 {
+		t.Errorf("Expected ratios [0 5], got %v", ratios)
+	}
+	if err := tableau.PivotAt(tableau.RowIndex("s2"), tableau.ColumnIndex("y")); !errors.Is(err, ErrPivotInfeasible) {
+		t.Errorf("Expected s1 to pass its upper bound, got %v", err)
+	}
+	if err := tableau.PivotAt(tableau.RowIndex("s1"), tableau.ColumnIndex("y")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := tableau.PivotAt(tableau.RowIndex("s2"), tableau.ColumnIndex("x")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if solution := tableau.GetSolution(); solution["x"] != 5 || solution["y"] != 5 || solution["s1"] != 1 {
+		t.Errorf("Expected x = 5, y = 5 and s1 = 1, got %v", solution)
+	}
+
+	// Lowering s1 from its bound is limited only by its lower bound, so no row leaves
+	s1 := tableau.ColumnIndex("s1")
+	if columns := tableau.CandidateColumns(); len(columns) != 1 || columns[0] != s1 {
+		t.Errorf("Expected the complemented s1 as the only candidate, got %v", columns)
+	}
+	if ratios := tableau.RatioTest(s1); !math.IsInf(ratios[0], 1) || !math.IsInf(ratios[1], 1) {
+		t.Errorf("Expected no row to limit s1, got %v", ratios)
+	}
+	if err := tableau.PivotAt(tableau.RowIndex("x"), s1); !errors.Is(err, ErrPivotInfeasible) {
+		t.Errorf("Expected no pivot on s1, got %v", err)
+	}
+	if !tableau.Pivot() || !tableau.IsOptimal() || math.Abs(tableau.TableauValue-11) > 1e-9 {
+		t.Errorf("Expected the optimal tableau\n%v", tableau)
+	}
+}
+
+func TestFormatFraction(t *testing.T) {
+	cases := map[float64]string{
+		0.5:      "1/2",
+		-2.0 / 3: "-2/3",
+		4:        "4",
+		math.Pi:  "3.141592654",
+	}
+	for value, expected := range cases {
+		if result := formatFraction(value); result != expected {
+			t.Errorf("Expected %v, got %v", expected, result)
+		}
+	}
+}
+
+/* *********************************************************************************************************************
+Trace
+********************************************************************************************************************* */
+
+func TestSolveTrace(t *testing.T) {
+	lp, _ := newApplesProgram()
+
+	observed := 0
+	trace := &Trace{Fractions: true}
+	result := lp.Solve(WithTrace(trace), WithObserver(func(Iteration) { observed++ }))
+
+	if len(trace.Iterations) != 2 || observed != 2 || result.Iterations() != 2 {
+		t.Fatalf("Expected 2 iterations, got %v traced, %v observed and %v solved", len(trace.Iterations), observed, result.Iterations())
+	}
+
+	first := trace.Iterations[0]
+	if first.Entering != "Apples" || first.Leaving != "s2" || first.PivotElement != 3 {
+		t.Errorf("Expected Apples to replace s2 on 3, got %v replacing %v on %v", first.Entering, first.Leaving, first.PivotElement)
+	}
+	if first.Ratios[0] != 8 || first.Ratios[1] != 4 {
+		t.Errorf("Expected ratios [8 4], got %v", first.Ratios)
+	}
+	if first.Tableau.BasisNames[1] != "s2" {
+		t.Errorf("Expected the snapshot to be taken before the pivot, got basis %v", first.Tableau.BasisNames)
+	}
+	if trace.Status != LpStatusOptimal || trace.Final.TableauValue != 32 {
+		t.Errorf("Expected an optimal final tableau with value 32, got %v with %v", trace.Status, trace.Final.TableauValue)
+	}
+}
+
+func TestSolveTraceBoundMove(t *testing.T) {
+	// Once x and y are basic, the slack of the range enters and reaches its upper bound before either leaves
+	x, y := NewVariable("x"), NewVariable("y")
+	lp := NewLinearProgram()
+	lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(2, x), NewTerm(1, y)}))
+	lp.AddNamedRangeConstraint("band", NewExpression([]LpTerm{NewTerm(1, x), NewTerm(-1, y)}), 1, 2)
+	lp.AddNamedConstraint("most", NewExpression([]LpTerm{NewTerm(1, x), NewTerm(1, y)}), LpConstraintLE, 4)
+	lp.AddNamedConstraint("x", NewExpression([]LpTerm{NewTerm(1, x)}), LpConstraintLE, 3)
+
+	for _, options := range [][]SolverOption{nil, {WithExactArithmetic()}} {
+		observed := []IterationKind{}
+		trace := &Trace{}
+		result := lp.Solve(append(options, WithTrace(trace), WithObserver(func(it Iteration) { observed = append(observed, it.Kind) }))...)
+		if result.Status() != LpStatusOptimal || result.ObjectiveValue() != 7 {
+			t.Errorf("Expected 7, got %v %v", result.Status(), result.ObjectiveValue())
+		}
+		if len(trace.Iterations) != 3 || len(observed) != 3 || result.Iterations() != 3 {
+			t.Fatalf("Expected 3 iterations, got %v traced, %v observed and %v solved", len(trace.Iterations), len(observed), result.Iterations())
+		}
+
+		move := trace.Iterations[2]
+		if move.Kind != IterationBound || observed[2] != IterationBound || move.Number != 3 {
+			t.Errorf("Expected the third iteration to be a bound move, got %v", move.Kind)
+		}
+		if move.Entering != "s1" || move.Leaving != "" || move.PivotRow != -1 || move.Tableau.BasisNames[0] != "x" {
+			t.Errorf("Expected s1 to move without a pivot, got %v replacing %v in row %v", move.Entering, move.Leaving, move.PivotRow)
+		}
+		if trace.Iterations[0].Kind != IterationPivot {
+			t.Errorf("Expected the first iteration to be a pivot, got %v", trace.Iterations[0].Kind)
+		}
+
+		text := strings.Builder{}
+		if err := trace.WriteText(&text); err != nil || !strings.Contains(text.String(), "Iteration 3: s1 moves to the other end of its range") {
+			t.Errorf("Expected the bound move in the text trace, got %v\n%v", err, text.String())
+		}
+		latex := strings.Builder{}
+		if err := trace.WriteLaTeX(&latex); err != nil || strings.Count(latex.String(), "\\boxed") != 2 {
+			t.Errorf("Expected only the two pivots boxed, got %v\n%v", err, latex.String())
+		}
+		js := strings.Builder{}
+		if err := trace.WriteJSON(&js); err != nil || !strings.Contains(js.String(), `"kind": "bound"`) {
+			t.Errorf("Expected the bound move in the JSON trace, got %v\n%v", err, js.String())
+		}
+
+		// The bound move counts towards the limit
+		if result := lp.Solve(append(options, WithIterationLimit(2))...); result.Status() != LpStatusIterationLimit {
+			t.Errorf("Expected %v, got %v", LpStatusIterationLimit, result.Status())
+		}
+	}
+}
+
+func TestTraceExports(t *testing.T) {
+	lp, _ := newApplesProgram()
+	trace := &Trace{Fractions: true}
+	lp.Solve(WithTrace(trace))
+
+	text := strings.Builder{}
+	if err := trace.WriteText(&text); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(text.String(), "Iteration 2: Bananas enters, s1 leaves, pivot element 8/3") {
+		t.Errorf("Expected the second iteration in the text trace, got\n%v", text.String())
+	}
+
+	latex := strings.Builder{}
+	if err := trace.WriteLaTeX(&latex); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Count(latex.String(), "\\begin{tabular}") != 3 || !strings.Contains(latex.String(), "\\boxed{$\\frac{8}{3}$}") {
+		t.Errorf("Expected three tabulars with the pivot boxed, got\n%v", latex.String())
+	}
+
+	var decoded struct {
+		Status     string
+		Iterations []struct {
+			Entering string
+			Ratios   []*float64
 		}
 	}
+	js := strings.Builder{}
+	if err := trace.WriteJSON(&js); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := json.Unmarshal([]byte(js.String()), &decoded); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if decoded.Status != "Optimal" || len(decoded.Iterations) != 2 || decoded.Iterations[1].Entering != "Bananas" {
+		t.Errorf("Unexpected JSON trace %v", js.String())
+	}
+}
+
+/* *********************************************************************************************************************
+Export
+********************************************************************************************************************* */
+
+func TestLinearProgramLaTeX(t *testing.T) {
+	lp, _ := newExampleProgram()
+
+	expected := `\begin{align*}
+\text{minimise} \quad & - 6x_{1} + 7x_{2} + 4x_{3} \\
+\text{subject to} \quad & 2x_{1} + 5x_{2} - x_{3} \leq 18 \tag{first} \\
+& x_{1} - x_{2} - 2x_{3} \leq -14 \tag{second} \\
+& 3x_{1} + 2x_{2} + 2x_{3} = 26 \tag{third} \\
+& x_{1}, x_{2}, x_{3} \geq 0
+\end{align*}
+`
+	if lp.LaTeX() != expected {
+		t.Errorf("Expected\n%v\ngot\n%v", expected, lp.LaTeX())
+	}
+}
+
+func TestLinearProgramMarkdown(t *testing.T) {
+	lp, _ := newApplesProgram()
+
+	expected := `**Maximise** 7 Apples + 6 Bananas
+
+**Subject to**
+
+| Constraint | Expression | | RHS |
+| --- | --- | :---: | ---: |
+| water | 2 Apples + 4 Bananas | ≤ | 16 |
+| land | 3 Apples + 2 Bananas | ≤ | 12 |
+
+Apples, Bananas ≥ 0
+`
+	if lp.Markdown() != expected {
+		t.Errorf("Expected\n%v\ngot\n%v", expected, lp.Markdown())
+	}
+}
+
+func TestTableauExports(t *testing.T) {
+	lp, _ := newApplesProgram()
+	tableau := NewTableau(lp)
+	tableau.Pivot()
+
+	expected := `| Basis | C\_B | Apples | Bananas | s1 | s2 | b |
+| --- | ---: | ---: | ---: | ---: | ---: | ---: |
+| **C\_j** |  | 7 | 6 | 0 | 0 |  |
+| s1 | 0 | 0 | 8/3 | 1 | -2/3 | 8 |
+| Apples | 7 | 1 | 2/3 | 0 | 1/3 | 4 |
+| **Z** |  | 7 | 14/3 | 0 | 7/3 | 28 |
+| **C-Z** |  | 0 | 4/3 | 0 | -7/3 |  |
+`
+	if tableau.Markdown(true) != expected {
+		t.Errorf("Expected\n%v\ngot\n%v", expected, tableau.Markdown(true))
+	}
+
+	latex := tableau.LaTeX(true)
+	if !strings.HasPrefix(latex, "\\begin{tabular}{l|r|rrrr|r}") || !strings.Contains(latex, "\\text{Apples} & $7$ & $1$ & $\\frac{2}{3}$") {
+		t.Errorf("Unexpected LaTeX tableau\n%v", latex)
+	}
+}
+
+/* *********************************************************************************************************************
+Exact Arithmetic
+********************************************************************************************************************* */
+
+func TestSolveExact(t *testing.T) {
+	lp, x := newExampleProgram()
+	result := lp.Solve(WithExactArithmetic())
+
+	if !result.IsExact() || result.Status() != LpStatusOptimal {
+		t.Fatalf("Expected an exact optimal result, got %v", result.Status())
+	}
+	if result.RatObjectiveValue().Cmp(big.NewRat(16, 1)) != 0 {
+		t.Errorf("Expected %v, got %v", 16, result.RatObjectiveValue())
+	}
+	if result.RatValue(x[2]).Cmp(big.NewRat(17, 2)) != 0 {
+		t.Errorf("Expected %v, got %v", "17/2", result.RatValue(x[2]))
+	}
+	if result.RatDual("second").Cmp(big.NewRat(-3, 1)) != 0 {
+		t.Errorf("Expected %v, got %v", -3, result.RatDual("second"))
+	}
+
+	sb := strings.Builder{}
+	if err := result.WriteCSV(&sb); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(sb.String(), "variable,x3,17/2,0,,,") || !strings.Contains(sb.String(), "constraint,first,,,-5/2,41/2,0") {
+		t.Errorf("Expected fractions in the report, got\n%v", sb.String())
+	}
+}
+
+func TestSolveExactAvoidsRoundOff(t *testing.T) {
+	// Maximise x subject to 0.1 * x <= 0.3
+	x := NewVariable("x")
+	lp := NewLinearProgram()
+	lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(1, x)})).
+		AddConstraint(NewExpression([]LpTerm{NewTerm(0.1, x)}), LpConstraintLE, 0.3)
+
+	if inexact := lp.Solve(); inexact.Value(x) == 3 || inexact.IsExact() {
+		t.Errorf("Expected float64 round-off, got exactly %v", inexact.Value(x))
+	}
+	if exact := lp.Solve(WithExactArithmetic()); exact.RatValue(x).Cmp(big.NewRat(3, 1)) != 0 || exact.Value(x) != 3 {
+		t.Errorf("Expected exactly 3, got %v", exact.RatValue(x))
+	}
+}
+
+func TestRationalTableau(t *testing.T) {
+	lp, _ := newApplesProgram()
+	tableau := NewRationalTableau(lp)
+	for tableau.Pivot() {
+	}
+
+	if tableau.TableauValue.Cmp(big.NewRat(32, 1)) != 0 {
+		t.Errorf("Expected %v, got %v", 32, tableau.TableauValue)
+	}
+	if !strings.Contains(tableau.String(), "Bananas |   6 |      0 |       1 |  3/8 | -1/4 |  3") {
+		t.Errorf("Unexpected tableau\n%v", tableau.String())
+	}
+}
+
+/* *********************************************************************************************************************
+Model Files
+********************************************************************************************************************* */
+
+// checkApplesModel Check that a model read from a file solves like newApplesProgram
+func checkApplesModel(t *testing.T, lp *LinearProgram, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(lp.Constraints) != 2 || lp.Constraints[0].Name != "water" || lp.Constraints[1].Name != "land" {
+		t.Fatalf("Expected constraints water and land, got %v", lp.Constraints)
+	}
+	if lp.VariableIndex("Apples") != 0 || lp.VariableIndex("Bananas") != 1 {
+		t.Errorf("Expected variables in file order, got %v", lp.Variables())
+	}
+
+	result := lp.Solve()
+	if result.Status() != LpStatusOptimal || math.Abs(result.ObjectiveValue()-32) > 1e-9 {
+		t.Errorf("Expected optimal value 32, got %v %v", result.Status(), result.ObjectiveValue())
+	}
+}
+
+func TestReadLP(t *testing.T) {
+	lp, err := ReadLP(strings.NewReader(`\ The apples example
+Maximize
+ profit: 7 Apples + 6Bananas
+Subject To
+ water: 2 Apples
+   + 4 Bananas <= 16
+ land: 3 Apples + 2 Bananas =< 12
+End`))
+	checkApplesModel(t, lp, err)
+
+	lp, err = ReadLP(strings.NewReader("Minimize\n obj: x + y\nst\n x - y >= -2\n x + y >= 1\nend"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if lp.Constraints[0].Name != "c1" || lp.Constraints[0].RightHandSide != -2 || lp.Constraints[1].ConstraintType != LpConstraintGE {
+		t.Errorf("Unexpected constraints %v %v", lp.Constraints[0], lp.Constraints[1])
+	}
+	if result := lp.Solve(); math.Abs(result.ObjectiveValue()-1) > 1e-9 {
+		t.Errorf("Expected %v, got %v", 1, result.ObjectiveValue())
+	}
+}
+
+func TestReadLPCategories(t *testing.T) {
+	lp, err := ReadLP(strings.NewReader(`Maximize
+ 3 x + 2 y + z
+Subject To
+ cap: 2 x + 2 y + z <= 7
+General
+ x
+Binaries
+ y
+End`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	x, _ := lp.Variable("x")
+	y, _ := lp.Variable("y")
+	z, _ := lp.Variable("z")
+	if lp.Category(x) != LpInteger || lp.Category(y) != LpBinary || lp.Category(z) != LpContinuous {
+		t.Errorf("Expected x integer, y binary and z continuous, got %v %v %v", lp.Category(x), lp.Category(y), lp.Category(z))
+	}
+	// The relaxation puts x at 3.5
+	if result := lp.Solve(); result.Status() != LpStatusOptimal || math.Abs(result.ObjectiveValue()-10) > 1e-9 || result.Value(x) != 3 {
+		t.Errorf("Expected 10 with x = 3, got %v %v with x = %v", result.Status(), result.ObjectiveValue(), result.Value(x))
+	}
+}
+
+func TestReadLPErrors(t *testing.T) {
+	for _, text := range []string{
+		"Subject To\n x <= 1",
+		"Maximize\nSubject To\n x <= 1",
+		"Maximize x\nSubject To\n x + 1 <= 1",
+		"Maximize x\nSubject To\n x <= y",
+		"Maximize x\nSubject To\n a: x <= 1\n a: x <= 2",
+		"Maximize x\nSubject To\n s1 <= 1",
+		"Maximize x\nBounds\n x <= 4",
+		"Maximize x\nSubject To\n x <= 4\nGeneral\n x 2",
+		"Maximize x\nSubject To\n x <= 4\nBinary\n s1",
+	} {
+		if _, err := ReadLP(strings.NewReader(text)); err == nil {
+			t.Errorf("Expected an error reading %q", text)
+		}
+	}
+}
+
+func TestParseConstraint(t *testing.T) {
+	c, err := ParseConstraint("water: 2 Apples + 4 Bananas <= 16")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if c.Name != "water" || len(c.Terms) != 2 || c.Terms[1].Coefficient != 4 || c.ConstraintType != LpConstraintLE || c.RightHandSide != 16 {
+		t.Errorf("Unexpected constraint %v", c)
+	}
+
+	expression, err := ParseExpression("- x + 2.5e1 y")
+	if err != nil || len(expression.Terms) != 2 || expression.Terms[0].Coefficient != -1 || expression.Terms[1].Coefficient != 25 {
+		t.Errorf("Unexpected expression %v, %v", expression, err)
+	}
+	if _, err := ParseConstraint("x <= 1 y"); err == nil {
+		t.Errorf("Expected an error for trailing input")
+	}
+}
+
+func TestReadMPS(t *testing.T) {
+	lp, err := ReadMPS(strings.NewReader(`NAME apples
+* The apples example
+OBJSENSE
+    MAX
+ROWS
+ N  profit
+ L  water
+ L  land
+COLUMNS
+    Apples    profit  7   water  2
+    Apples    land    3
+    Bananas   profit  6   water  4
+    Bananas   land    2
+RHS
+    RHS       water   16  land   12
+ENDATA`))
+	checkApplesModel(t, lp, err)
+
+	for _, text := range []string{
+		"ROWS\n N obj\nCOLUMNS\n x obj 1 c1 2\nENDATA",
+		"ROWS\n N obj\n L c1\nCOLUMNS\n x obj 1 c1 2\nBOUNDS\n UP BND x 4\nENDATA",
+		"ROWS\n N obj\n L c1\nCOLUMNS\n x obj 1 c1 2\nRHS\n RHS obj 3\nENDATA",
+		"ROWS\n N obj\n L c1\nCOLUMNS\n x c1 2\nENDATA",
+	} {
+		if _, err := ReadMPS(strings.NewReader(text)); err == nil {
+			t.Errorf("Expected an error reading %q", text)
+		}
+	}
+}
+
+func TestReadJSON(t *testing.T) {
+	lp, err := ReadJSON(strings.NewReader(`{
+  "sense": "maximise",
+  "variables": [{"name": "Apples"}, {"name": "Bananas"}],
+  "objective": [{"name": "Bananas", "coefficient": 6}, {"name": "Apples", "coefficient": 7}],
+  "constraints": [
+    {"name": "water", "terms": [{"name": "Apples", "coefficient": 2}, {"name": "Bananas", "coefficient": 4}], "type": "<=", "rhs": 16},
+    {"name": "land", "terms": [{"name": "Apples", "coefficient": 3}, {"name": "Bananas", "coefficient": 2}], "type": "LE", "rhs": 12}
+  ]
+}`))
+	checkApplesModel(t, lp, err)
+
+	for _, text := range []string{
+		`{"sense": "up", "objective": [{"name": "x", "coefficient": 1}]}`,
+		`{"sense": "max", "objective": []}`,
+		`{"sense": "max", "objective": [{"name": "x", "coefficient": 1}], "constraints": [{"terms": [{"name": "x", "coefficient": 1}], "type": "<>", "rhs": 1}]}`,
+		`{"sense": "max", "objective": [{"name": "", "coefficient": 1}]}`,
+		`{"sense": "max", "objective": [{"name": "x", "coefficient": 1}], "bounds": []}`,
+	} {
+		if _, err := ReadJSON(strings.NewReader(text)); err == nil {
+			t.Errorf("Expected an error reading %q", text)
+		}
+	}
+}
+
+/* *********************************************************************************************************************
+Presolve
+********************************************************************************************************************* */
+
+// newPresolveProgram Build a model with an empty row, bounds, a fixed variable and a duplicated constraint
+func newPresolveProgram() (*LinearProgram, []LpVariable) {
+	x := []LpVariable{NewVariable("x"), NewVariable("y"), NewVariable("z")}
+
+	lp := NewLinearProgram()
+	lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(3, x[0]), NewTerm(2, x[1]), NewTerm(1, x[2])}))
+	lp.AddNamedConstraint("total", NewExpression([]LpTerm{NewTerm(1, x[0]), NewTerm(1, x[1]), NewTerm(1, x[2])}), LpConstraintLE, 10)
+	lp.AddNamedConstraint("double", NewExpression([]LpTerm{NewTerm(2, x[0]), NewTerm(2, x[1]), NewTerm(2, x[2])}), LpConstraintLE, 30)
+	lp.AddNamedConstraint("xcap", NewExpression([]LpTerm{NewTerm(1, x[0])}), LpConstraintLE, 4)
+	lp.AddNamedConstraint("zfix", NewExpression([]LpTerm{NewTerm(2, x[2])}), LpConstraintEQ, 2)
+	lp.AddNamedConstraint("empty", NewExpression([]LpTerm{NewTerm(0, x[0])}), LpConstraintLE, 5)
+	lp.AddNamedConstraint("ymin", NewExpression([]LpTerm{NewTerm(-1, x[1])}), LpConstraintLE, -1)
+	return &lp, x
+}
+
+// checkSameResult Check that two results agree on every value they report
+func checkSameResult(t *testing.T, expected, result *Result) {
+	t.Helper()
+	if result.Status() != expected.Status() || math.Abs(result.ObjectiveValue()-expected.ObjectiveValue()) > 1e-9 {
+		t.Fatalf("Expected %v %v, got %v %v", expected.Status(), expected.ObjectiveValue(), result.Status(), result.ObjectiveValue())
+	}
+	for _, v := range expected.Variables() {
+		if math.Abs(result.Value(v)-expected.Value(v)) > 1e-9 || math.Abs(result.ReducedCost(v)-expected.ReducedCost(v)) > 1e-9 {
+			t.Errorf("Variable %v: expected %v (%v), got %v (%v)", v.Name, expected.Value(v), expected.ReducedCost(v), result.Value(v), result.ReducedCost(v))
+		}
+	}
+	for _, name := range expected.Constraints() {
+		if math.Abs(result.Activity(name)-expected.Activity(name)) > 1e-9 || math.Abs(result.Dual(name)-expected.Dual(name)) > 1e-9 {
+			t.Errorf("Constraint %v: expected %v (%v), got %v (%v)", name, expected.Activity(name), expected.Dual(name), result.Activity(name), result.Dual(name))
+		}
+	}
+}
+
+func TestPresolve(t *testing.T) {
+	lp, _ := newPresolveProgram()
+	presolved := lp.Presolve()
+
+	if presolved.Status != LpStatusNotSolved || presolved.Model == nil {
+		t.Fatalf("Expected a reduced model, got %v", presolved.Status)
+	}
+	// z is fixed at 1 and y is shifted by its lower bound of 1
+	expected := "Max: 3 * x + 2 * y\n\t1 * x + 1 * y <= 8\n\t1 * x <= 4"
+	if model := presolved.Model.String(); model != expected {
+		t.Errorf("Expected\n%v\ngot\n%v", expected, model)
+	}
+	if presolved.Model.Constraints[0].Name != "total" || presolved.Model.Constraints[1].Name != "xcap" {
+		t.Errorf("Expected rows total and xcap, got %v", presolved.Model.Constraints)
+	}
+	for _, reduction := range []string{"Fixed z = 1", "Removed empty row empty", "Row xcap is a bound: x <= 4", "Row ymin is a bound: y >= 1", "Removed row double, a duplicate of total"} {
+		if !strings.Contains(strings.Join(presolved.Reductions, "\n"), reduction) {
+			t.Errorf("Expected reduction %q in\n%v", reduction, strings.Join(presolved.Reductions, "\n"))
+		}
+	}
+
+	result := presolved.Postsolve(presolved.Model.Solve())
+	checkSameResult(t, lp.Solve(), result)
+	if result.ObjectiveValue() != 23 || result.Dual("xcap") != 1 || result.Dual("zfix") != -0.5 {
+		t.Errorf("Unexpected result\n%v %v %v", result.ObjectiveValue(), result.Dual("xcap"), result.Dual("zfix"))
+	}
+}
+
+func TestSolveWithPresolve(t *testing.T) {
+	lp, _ := newExampleProgram()
+	checkSameResult(t, lp.Solve(), lp.Solve(WithPresolve()))
+
+	lp, _ = newPresolveProgram()
+	checkSameResult(t, lp.Solve(), lp.Solve(WithPresolve()))
+
+	// Fixing a and c leaves c3 a singleton, removed after c1, so its dual is needed to price a
+	a, b, c := NewVariable("a"), NewVariable("b"), NewVariable("c")
+	model := NewLinearProgram()
+	model.AddObjective(LpMinimise, NewExpression([]LpTerm{NewTerm(3, a), NewTerm(4, b), NewTerm(-4, c)}))
+	model.AddConstraint(NewExpression([]LpTerm{NewTerm(5, a)}), LpConstraintEQ, 9)
+	model.AddConstraint(NewExpression([]LpTerm{NewTerm(4, c)}), LpConstraintEQ, 15)
+	model.AddConstraint(NewExpression([]LpTerm{NewTerm(5, a), NewTerm(1, b)}), LpConstraintEQ, 15)
+	result := model.Solve(WithPresolve())
+	checkSameResult(t, model.Solve(), result)
+	if math.Abs(result.Dual("c1")+3.4) > 1e-9 || math.Abs(result.Dual("c3")-4) > 1e-9 {
+		t.Errorf("Expected duals -3.4 and 4, got %v %v", result.Dual("c1"), result.Dual("c3"))
+	}
+
+	// Fixing both variables leaves nothing to solve but the elastic row
+	x, y := NewVariable("x"), NewVariable("y")
+	model = NewLinearProgram()
+	model.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(1, x), NewTerm(1, y)}))
+	model.AddNamedConstraint("fx", NewExpression([]LpTerm{NewTerm(1, x)}), LpConstraintEQ, 2)
+	model.AddNamedConstraint("fy", NewExpression([]LpTerm{NewTerm(1, y)}), LpConstraintEQ, 3)
+	model.AddNamedConstraint("soft", NewExpression([]LpTerm{NewTerm(1, x), NewTerm(1, y)}), LpConstraintLE, 4)
+	model.SetElastic("soft", 10)
+	result = model.Solve(WithPresolve())
+	checkSameResult(t, model.Solve(), result)
+	if result.ObjectiveValue() != -5 || result.Violation("soft") != 1 {
+		t.Errorf("Expected -5 with a violation of 1, got %v %v", result.ObjectiveValue(), result.Violation("soft"))
+	}
+}
+
+func TestPresolveSettlesProblem(t *testing.T) {
+	x, y := NewVariable("x"), NewVariable("y")
+	objective := NewExpression([]LpTerm{NewTerm(1, x), NewTerm(1, y)})
+
+	lp := NewLinearProgram()
+	lp.AddObjective(LpMinimise, objective).
+		AddConstraint(NewExpression([]LpTerm{NewTerm(1, x)}), LpConstraintGE, 5).
+		AddConstraint(NewExpression([]LpTerm{NewTerm(1, x)}), LpConstraintLE, 3)
+	if result := lp.Solve(WithPresolve()); result.Status() != LpStatusInfeasible || result.Iterations() != 0 {
+		t.Errorf("Expected infeasibility without iterations, got %v after %v", result.Status(), result.Iterations())
+	}
+
+	lp = NewLinearProgram()
+	lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(1, x), NewTerm(1, y)})).
+		AddConstraint(NewExpression([]LpTerm{NewTerm(1, x), NewTerm(1, y)}), LpConstraintEQ, 4).
+		AddConstraint(NewExpression([]LpTerm{NewTerm(2, x), NewTerm(2, y)}), LpConstraintEQ, 10)
+	if presolved := lp.Presolve(); presolved.Status != LpStatusInfeasible || presolved.Model != nil {
+		t.Errorf("Expected conflicting duplicates to be infeasible, got %v", presolved.Status)
+	}
+
+	lp = NewLinearProgram()
+	lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(2, x), NewTerm(1, y)})).
+		AddNamedConstraint("fixx", NewExpression([]LpTerm{NewTerm(1, x)}), LpConstraintEQ, 2)
+	lp.AddNamedConstraint("fixy", NewExpression([]LpTerm{NewTerm(1, y)}), LpConstraintEQ, 3)
+	result := lp.Solve(WithPresolve())
+	if result.Status() != LpStatusOptimal || result.ObjectiveValue() != 7 || result.Dual("fixx") != 2 || result.Dual("fixy") != 1 {
+		t.Errorf("Expected 7 with duals 2 and 1, got %v %v %v %v", result.Status(), result.ObjectiveValue(), result.Dual("fixx"), result.Dual("fixy"))
+	}
+}
+
+/* *********************************************************************************************************************
+Scaling
+********************************************************************************************************************* */
+
+func TestSolveWithScaling(t *testing.T) {
+	lp, _ := newExampleProgram()
+	checkSameResult(t, lp.Solve(), lp.Solve(WithScaling()))
+
+	lp, _ = newApplesProgram()
+	checkSameResult(t, lp.Solve(), lp.Solve(WithScalingMethod(ScaleEquilibration)))
+}
+
+func TestScaleBadlyScaledProgram(t *testing.T) {
+	// Maximise 2x + y subject to x <= 1 and x + y <= 3, written with coefficients 16 orders of magnitude apart
+	x, y := NewVariable("x"), NewVariable("y")
+	lp := NewLinearProgram()
+	lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(2, x), NewTerm(1, y)}))
+	lp.AddNamedConstraint("small", NewExpression([]LpTerm{NewTerm(1e-10, x)}), LpConstraintLE, 1e-10)
+	lp.AddNamedConstraint("big", NewExpression([]LpTerm{NewTerm(1e6, x), NewTerm(1e6, y)}), LpConstraintLE, 3e6)
+
+	// Unscaled, the small coefficient falls below the tolerance and x is pushed past its limit
+	if result := lp.Solve(); result.Value(x) == 1 {
+		t.Errorf("Expected the unscaled solve to go wrong, got x = %v", result.Value(x))
+	}
+
+	scaled := lp.Scale(ScaleGeometricEquilibration)
+	if scaled.Before.Ratio() != 1e16 || scaled.After.Ratio() > 2 {
+		t.Errorf("Expected the coefficient range to shrink, got %v to %v", scaled.Before, scaled.After)
+	}
+	for _, s := range append(scaled.RowScale, scaled.ColumnScale...) {
+		if math.Exp2(math.Round(math.Log2(s))) != s {
+			t.Errorf("Expected scale factors to be powers of two, got %v", s)
+		}
+	}
+
+	result := lp.Solve(WithScaling())
+	if result.Status() != LpStatusOptimal || math.Abs(result.Value(x)-1) > 1e-9 || math.Abs(result.Value(y)-2) > 1e-9 {
+		t.Fatalf("Expected x = 1, y = 2, got %v %v %v", result.Status(), result.Value(x), result.Value(y))
+	}
+	if math.Abs(result.Dual("small")-1e10) > 1e-3 || math.Abs(result.Dual("big")-1e-6) > 1e-15 || math.Abs(result.Slack("big")) > 1e-6 {
+		t.Errorf("Expected duals 1e10 and 1e-6, got %v %v", result.Dual("small"), result.Dual("big"))
+	}
+}
+
+/* *********************************************************************************************************************
+Stats
+********************************************************************************************************************* */
+
+func TestStats(t *testing.T) {
+	lp, _ := newExampleProgram()
+	stats := lp.Stats()
+
+	if stats.Variables != 3 || stats.Constraints != 3 || stats.LessEqual != 2 || stats.Equal != 1 || stats.NonZeros != 9 || stats.Density != 1 {
+		t.Errorf("Unexpected counts %+v", stats)
+	}
+	if stats.Coefficients != (CoefficientRange{1, 5}) || stats.Objective != (CoefficientRange{4, 7}) || stats.RightHandSide != (CoefficientRange{14, 26}) {
+		t.Errorf("Unexpected ranges %v %v %v", stats.Coefficients, stats.Objective, stats.RightHandSide)
+	}
+	if len(stats.Warnings) != 0 {
+		t.Errorf("Expected no warnings, got %v", stats.Warnings)
+	}
+	if !strings.Contains(stats.String(), "Constraints:      3 (2 <=, 0 >=, 1 =)") {
+		t.Errorf("Unexpected report\n%v", stats)
+	}
+}
+
+func TestStatsWarnings(t *testing.T) {
+	x, y, z, b := NewVariable("x"), NewVariable("y"), NewVariable("z"), NewVariable("b")
+	lp := NewLinearProgram()
+	lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(1e18, x), NewTerm(1, y), NewTerm(1, z)}))
+	lp.AddNamedConstraint("tiny", NewExpression([]LpTerm{NewTerm(1e-12, x), NewTerm(1e3, y)}), LpConstraintLE, 1)
+	lp.AddNamedConstraint("cap", NewExpression([]LpTerm{NewTerm(1, y)}), LpConstraintLE, 4)
+	lp.AddNamedConstraint("empty", NewExpression([]LpTerm{NewTerm(0, x)}), LpConstraintGE, 0)
+	lp.SetCategory(b, LpBinary)
+
+	stats := lp.Stats()
+	if stats.Bounded != 2 || stats.Integer != 1 || stats.Variables != 4 {
+		t.Errorf("Unexpected variable counts %+v", stats)
+	}
+	expected := []string{
+		"Objective coefficient of x is 1e+18, close to the big-M penalty of 1e+20",
+		"Row tiny: coefficient of x is 1e-12, below the solver tolerance of 1e-09",
+		"Row empty has no non-zero coefficients",
+		"Variable z appears in no constraint",
+		"Variable b appears in no constraint",
+		"Coefficients range over a ratio of 1e+15, consider WithScaling",
+	}
+	if strings.Join(stats.Warnings, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected warnings\n%v\ngot\n%v", strings.Join(expected, "\n"), strings.Join(stats.Warnings, "\n"))
+	}
+}
+
+func TestSetCategory(t *testing.T) {
+	lp, x := newApplesProgram()
+	if lp.Category(x[0]) != LpContinuous {
+		t.Errorf("Expected %v, got %v", LpContinuous, lp.Category(x[0]))
+	}
+
+	lp.SetCategory(x[0], LpInteger)
+	if lp.Category(x[0]) != LpInteger {
+		t.Errorf("Expected %v, got %v", LpInteger, lp.Category(x[0]))
+	}
+	if result := lp.Solve(); result.Status() != LpStatusOptimal || math.Abs(result.ObjectiveValue()-32) > 0.0001 {
+		t.Errorf("Expected %v 32, got %v %v", LpStatusOptimal, result.Status(), result.ObjectiveValue())
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected a panic for an unknown category")
+		}
+	}()
+	lp.SetCategory(x[1], LpCategory("Boolean"))
+}
+
+/* *********************************************************************************************************************
+Infeasibility Diagnosis
+********************************************************************************************************************* */
+
+func TestComputeIIS(t *testing.T) {
+	x, y := NewVariable("x"), NewVariable("y")
+	lp := NewLinearProgram()
+	lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(1, x), NewTerm(1, y)}))
+	lp.AddNamedConstraint("ycap", NewExpression([]LpTerm{NewTerm(1, y)}), LpConstraintLE, 10)
+	lp.AddNamedConstraint("total", NewExpression([]LpTerm{NewTerm(1, x), NewTerm(1, y)}), LpConstraintLE, 2)
+	lp.AddNamedConstraint("xmin", NewExpression([]LpTerm{NewTerm(1, x)}), LpConstraintGE, 3)
+
+	iis, err := lp.ComputeIIS()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Without y >= 0, y = -1 and x = 3 would satisfy both constraints
+	if strings.Join(iis.Constraints, ",") != "total,xmin" || strings.Join(iis.Bounds, ",") != "y" {
+		t.Errorf("Expected total, xmin and y >= 0, got %v %v", iis.Constraints, iis.Bounds)
+	}
+	if iis.String() != "total\nxmin\ny >= 0\n" {
+		t.Errorf("Unexpected IIS\n%v", iis)
+	}
+	if len(lp.Constraints) != 3 || lp.Solve().Status() != LpStatusInfeasible {
+		t.Errorf("Expected the linear program to be left unchanged")
+	}
+
+	feasible, _ := newApplesProgram()
+	if _, err := feasible.ComputeIIS(); !errors.Is(err, ErrFeasible) {
+		t.Errorf("Expected %v, got %v", ErrFeasible, err)
+	}
+}
+
+/* *********************************************************************************************************************
+Elastic Constraints
+********************************************************************************************************************* */
+
+func TestSetElastic(t *testing.T) {
+	// The infeasible model from TestComputeIIS, with xmin softened
+	x, y := NewVariable("x"), NewVariable("y")
+	lp := NewLinearProgram()
+	lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(1, x), NewTerm(1, y)}))
+	lp.AddNamedConstraint("total", NewExpression([]LpTerm{NewTerm(1, x), NewTerm(1, y)}), LpConstraintLE, 2)
+	lp.AddNamedConstraint("xmin", NewExpression([]LpTerm{NewTerm(1, x)}), LpConstraintGE, 3)
+	lp.SetElastic("xmin", 2)
+
+	// Each unit x falls short of 3 costs 2, so x takes all of the total
+	result := lp.Solve()
+	if result.Status() != LpStatusOptimal || result.ObjectiveValue() != 0 || result.Value(x) != 2 {
+		t.Fatalf("Expected x = 2 with objective 0, got %v %v %v", result.Status(), result.ObjectiveValue(), result.Value(x))
+	}
+	if result.Violation("xmin") != 1 || result.Violation("total") != 0 || result.Dual("xmin") != -2 {
+		t.Errorf("Expected xmin to be violated by 1 at a price of -2, got %v %v", result.Violation("xmin"), result.Dual("xmin"))
+	}
+	checkSameResult(t, result, lp.Solve(WithPresolve()))
+	checkSameResult(t, result, lp.Solve(WithScaling()))
+	if lp.Solve(WithPresolve()).Violation("xmin") != 1 || lp.Solve(WithScaling()).Violation("xmin") != 1 {
+		t.Errorf("Expected presolve and scaling to report the violation")
+	}
+	if exact := lp.Solve(WithExactArithmetic()); exact.Violation("xmin") != 1 {
+		t.Errorf("Expected the exact solve to report the violation, got %v", exact.Violation("xmin"))
+	}
+	if _, err := lp.ComputeIIS(); !errors.Is(err, ErrFeasible) {
+		t.Errorf("Expected %v, got %v", ErrFeasible, err)
+	}
+}
+
+func TestElasticConstraintTypes(t *testing.T) {
+	x, y := NewVariable("x"), NewVariable("y")
+
+	// Meeting x + y = 4 costs more than violating it
+	lp := NewLinearProgram()
+	lp.AddObjective(LpMinimise, NewExpression([]LpTerm{NewTerm(1, x), NewTerm(1, y)}))
+	lp.AddNamedConstraint("demand", NewExpression([]LpTerm{NewTerm(1, x), NewTerm(1, y)}), LpConstraintEQ, 4)
+	result := lp.SetElastic("demand", 0.5).Solve()
+	if result.ObjectiveValue() != 2 || result.Violation("demand") != 4 {
+		t.Errorf("Expected objective 2 with demand violated by 4, got %v %v", result.ObjectiveValue(), result.Violation("demand"))
+	}
+	result = lp.SetElastic("demand", 3).Solve()
+	if result.ObjectiveValue() != 4 || result.Violation("demand") != 0 {
+		t.Errorf("Expected objective 4 with demand met, got %v %v", result.ObjectiveValue(), result.Violation("demand"))
+	}
+
+	// A negative right hand side, y <= 1 written as -y >= -1
+	lp = NewLinearProgram()
+	lp.AddObjective(LpMinimise, NewExpression([]LpTerm{NewTerm(-1, y)}))
+	lp.AddNamedConstraint("ymax", NewExpression([]LpTerm{NewTerm(-1, y)}), LpConstraintGE, -1)
+	lp.AddNamedConstraint("ycap", NewExpression([]LpTerm{NewTerm(1, y)}), LpConstraintLE, 4)
+	result = lp.SetElastic("ymax", 0.5).Solve()
+	if result.ObjectiveValue() != -2.5 || result.Violation("ymax") != 3 {
+		t.Errorf("Expected objective -2.5 with ymax violated by 3, got %v %v", result.ObjectiveValue(), result.Violation("ymax"))
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic for a negative penalty")
+		}
+	}()
+	lp.SetElastic("ymax", -1)
+}
+
+/* *********************************************************************************************************************
+Multiple Objectives
+********************************************************************************************************************* */
+
+func TestSecondaryObjectivesLexicographic(t *testing.T) {
+	x, y, z := NewVariable("x"), NewVariable("y"), NewVariable("z")
+	lp := NewLinearProgram()
+	lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(1, x), NewTerm(1, y)}))
+	lp.AddNamedConstraint("total", NewExpression([]LpTerm{NewTerm(1, x), NewTerm(1, y)}), LpConstraintLE, 4)
+	lp.AddNamedConstraint("xcap", NewExpression([]LpTerm{NewTerm(1, x)}), LpConstraintLE, 3)
+	lp.AddNamedConstraint("zcap", NewExpression([]LpTerm{NewTerm(1, z)}), LpConstraintLE, 5)
+	lp.AddSecondaryObjective("balance", LpMinimise, NewExpression([]LpTerm{NewTerm(1, x)}))
+	lp.AddSecondaryObjective("spare", LpMaximise, NewExpression([]LpTerm{NewTerm(1, z)}))
+
+	for _, result := range []*Result{lp.Solve(), lp.Solve(WithExactArithmetic()), lp.Solve(WithPresolve())} {
+		if result.Status() != LpStatusOptimal || math.Abs(result.ObjectiveValue()-4) > 0.0001 {
+			t.Fatalf("Expected an objective of 4, got %v %v", result.Status(), result.ObjectiveValue())
+		}
+		if math.Abs(result.Value(x)) > 0.0001 || math.Abs(result.Value(y)-4) > 0.0001 || math.Abs(result.Value(z)-5) > 0.0001 {
+			t.Errorf("Expected x = 0, y = 4 and z = 5, got %v", result.Values())
+		}
+		if math.Abs(result.SecondaryObjectiveValue("balance")) > 0.0001 || math.Abs(result.SecondaryObjectiveValue("spare")-5) > 0.0001 {
+			t.Errorf("Expected balance 0 and spare 5, got %v %v", result.SecondaryObjectiveValue("balance"), result.SecondaryObjectiveValue("spare"))
+		}
+		if names := strings.Join(result.Constraints(), ","); names != "total,xcap,zcap" || len(result.Variables()) != 3 {
+			t.Errorf("Expected only the model's constraints and variables, got %v %v", names, result.Variables())
+		}
+	}
+
+	sb := strings.Builder{}
+	_ = lp.Solve().WriteText(&sb)
+	if !strings.Contains(sb.String(), "Objective balance:  0\nObjective spare:    5") {
+		t.Errorf("Expected the secondary objectives in the report\n%v", sb.String())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic for a duplicate objective")
+		}
+	}()
+	lp.AddSecondaryObjective("spare", LpMinimise, NewExpression([]LpTerm{NewTerm(1, z)}))
+}
+
+func TestSecondaryObjectivesHold(t *testing.T) {
+	// The optimum 1/3 is not a float64, so only an exact hold keeps the second stage feasible
+	x, y := NewVariable("x"), NewVariable("y")
+	lp := NewLinearProgram()
+	lp.AddObjective(LpMinimise, NewExpression([]LpTerm{NewTerm(1, x)}))
+	lp.AddConstraint(NewExpression([]LpTerm{NewTerm(3, x)}), LpConstraintGE, 1)
+	lp.AddConstraint(NewExpression([]LpTerm{NewTerm(1, y)}), LpConstraintLE, 2)
+	lp.AddSecondaryObjective("spare", LpMaximise, NewExpression([]LpTerm{NewTerm(1, y)}))
+	for _, result := range []*Result{lp.Solve(), lp.Solve(WithExactArithmetic())} {
+		if result.Status() != LpStatusOptimal || math.Abs(result.Value(x)-1.0/3) > 0.0001 || math.Abs(result.Value(y)-2) > 0.0001 {
+			t.Errorf("Expected x = 1/3 and y = 2, got %v %v", result.Status(), result.Values())
+		}
+	}
+	if value := lp.Solve(WithExactArithmetic()).RatValue(x); value == nil || value.Cmp(big.NewRat(1, 3)) != 0 {
+		t.Errorf("Expected x = 1/3 exactly, got %v", value)
+	}
+
+	// The duals price the objective function, not the held row of the last stage
+	lp = NewLinearProgram()
+	lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(1, x), NewTerm(1, y)}))
+	lp.AddConstraint(NewExpression([]LpTerm{NewTerm(1, x), NewTerm(1, y)}), LpConstraintLE, 4)
+	lp.AddConstraint(NewExpression([]LpTerm{NewTerm(1, x)}), LpConstraintLE, 3)
+	lp.AddSecondaryObjective("spare", LpMaximise, NewExpression([]LpTerm{NewTerm(1, y)}))
+	result := lp.Solve()
+	if math.Abs(result.Dual("c1")-1) > 0.0001 || math.Abs(result.Dual("c2")) > 0.0001 || math.Abs(result.ReducedCost(x)) > 0.0001 {
+		t.Errorf("Expected the duals of the objective function, got %v %v %v", result.Dual("c1"), result.Dual("c2"), result.ReducedCost(x))
+	}
+}
+
+func TestSecondaryObjectivesElastic(t *testing.T) {
+	x, y := NewVariable("x"), NewVariable("y")
+	lp := NewLinearProgram()
+	lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(5, x), NewTerm(1, y)}))
+	lp.AddNamedConstraint("cap", NewExpression([]LpTerm{NewTerm(1, x), NewTerm(1, y)}), LpConstraintLE, 4)
+	lp.AddNamedConstraint("xmax", NewExpression([]LpTerm{NewTerm(1, x)}), LpConstraintLE, 10)
+	lp.SetElastic("cap", 2)
+	lp.AddSecondaryObjective("spare", LpMinimise, NewExpression([]LpTerm{NewTerm(1, y)}))
+
+	// The penalty on breaking cap by 6 counts against the objective, whatever the mode
+	for _, mode := range []ObjectiveMode{ObjectiveLexicographic, ObjectiveWeighted} {
+		for _, result := range []*Result{lp.Solve(WithObjectiveMode(mode)), lp.Solve(WithObjectiveMode(mode), WithExactArithmetic())} {
+			if math.Abs(result.ObjectiveValue()-38) > 0.0001 || math.Abs(result.Violation("cap")-6) > 0.0001 || math.Abs(result.Activity("cap")-10) > 0.0001 {
+				t.Errorf("Mode %v: expected 38 with cap broken by 6, got %v %v %v", mode, result.ObjectiveValue(), result.Violation("cap"), result.Activity("cap"))
+			}
+			if verification, err := lp.Verify(result); err != nil || !verification.OK(1e-6) {
+				t.Errorf("Mode %v: expected the result to verify, got %v %v", mode, verification, err)
+			}
+		}
+	}
+}
+
+func TestSecondaryObjectivesWeighted(t *testing.T) {
+	x, y := NewVariable("x"), NewVariable("y")
+	lp := NewLinearProgram()
+	lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(3, x), NewTerm(2, y)}))
+	lp.AddNamedConstraint("total", NewExpression([]LpTerm{NewTerm(1, x), NewTerm(1, y)}), LpConstraintLE, 4)
+	lp.AddNamedConstraint("xcap", NewExpression([]LpTerm{NewTerm(1, x)}), LpConstraintLE, 3)
+	hours := lp.AddSecondaryObjective("hours", LpMinimise, NewExpression([]LpTerm{NewTerm(1, x)}))
+
+	// Profit comes first, so the hours cannot be traded for it
+	result := lp.Solve()
+	if math.Abs(result.ObjectiveValue()-11) > 0.0001 || math.Abs(result.SecondaryObjectiveValue("hours")-3) > 0.0001 {
+		t.Errorf("Expected profit 11 and 3 hours, got %v %v", result.ObjectiveValue(), result.SecondaryObjectiveValue("hours"))
+	}
+
+	// Each hour costs 2, more than the extra profit x makes over y
+	hours.Weight = 2
+	result = lp.Solve(WithObjectiveMode(ObjectiveWeighted))
+	if result.ObjectiveValue() != 8 || result.SecondaryObjectiveValue("hours") != 0 || result.Value(y) != 4 {
+		t.Errorf("Expected profit 8 and no hours, got %v %v", result.ObjectiveValue(), result.SecondaryObjectiveValue("hours"))
+	}
+}
+
+/* *********************************************************************************************************************
+Parametric Analysis
+********************************************************************************************************************* */
+
+// checkSegments Check the range, value and slope of each segment of a value function
+func checkSegments(t *testing.T, f *ValueFunction, expected [][4]float64) {
+	t.Helper()
+	if len(f.Segments) != len(expected) {
+		t.Fatalf("Expected %v segments, got\n%v", len(expected), f)
+	}
+	for k, s := range f.Segments {
+		got := [4]float64{s.From, s.To, s.Value, s.Slope}
+		for i := range got {
+			if math.Abs(got[i]-expected[k][i]) > 1e-9 && !(math.IsNaN(got[i]) && math.IsNaN(expected[k][i])) {
+				t.Errorf("Segment %v: expected %v, got %v", k, expected[k], got)
+				break
+			}
+		}
+	}
+}
+
+func TestParametricRHS(t *testing.T) {
+	// The land available runs from 0 to 30
+	lp, _ := newApplesProgram()
+	f, err := lp.ParametricRHS(map[string]float64{"land": 1}, -12, 18)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	checkSegments(t, f, [][4]float64{{-12, -4, 0, 3}, {-4, 12, 24, 2}, {12, 18, 56, 0}})
+	if breakpoints := f.Breakpoints(); len(breakpoints) != 2 || math.Abs(breakpoints[0]+4) > 1e-9 || math.Abs(breakpoints[1]-12) > 1e-9 {
+		t.Errorf("Expected breakpoints at -4 and 12, got %v", breakpoints)
+	}
+	if math.Abs(f.Value(0)-lp.Solve().ObjectiveValue()) > 1e-9 || !math.IsNaN(f.Value(20)) {
+		t.Errorf("Expected the value at 0 to match a solve, got %v", f.Value(0))
+	}
+
+	// Minimise x with x >= θ, which becomes infeasible past x <= 5
+	x := NewVariable("x")
+	bounded := NewLinearProgram()
+	bounded.AddObjective(LpMinimise, NewExpression([]LpTerm{NewTerm(1, x)}))
+	bounded.AddNamedConstraint("xmin", NewExpression([]LpTerm{NewTerm(1, x)}), LpConstraintGE, 0)
+	bounded.AddNamedConstraint("xmax", NewExpression([]LpTerm{NewTerm(1, x)}), LpConstraintLE, 5)
+	f, err = bounded.ParametricRHS(map[string]float64{"xmin": 1}, 0, 10)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	checkSegments(t, f, [][4]float64{{0, 5, 0, 1}, {5, 10, math.NaN(), 0}})
+	if f.Segments[1].Status != LpStatusInfeasible {
+		t.Errorf("Expected the sweep to end infeasible, got %v", f.Segments[1].Status)
+	}
+
+	if _, err := bounded.ParametricRHS(map[string]float64{"xmin": 1}, 6, 10); err == nil {
+		t.Errorf("Expected an error for an infeasible start")
+	}
+}
+
+func TestParametricObjective(t *testing.T) {
+	// The profit on apples runs from 0 to 20
+	lp, _ := newApplesProgram()
+	f, err := lp.ParametricObjective(map[string]float64{"Apples": 1}, -7, 13)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	checkSegments(t, f, [][4]float64{{-7, -4, 24, 0}, {-4, 2, 24, 2}, {2, 13, 36, 4}})
+	if math.Abs(f.Value(13)-80) > 1e-9 {
+		t.Errorf("Expected 80 at the end of the sweep, got %v", f.Value(13))
+	}
+
+	// Maximise θx - y with x - y <= 1, unbounded once x is worth more than y
+	x, y := NewVariable("x"), NewVariable("y")
+	unbounded := NewLinearProgram()
+	unbounded.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(0, x), NewTerm(-1, y)}))
+	unbounded.AddNamedConstraint("gap", NewExpression([]LpTerm{NewTerm(1, x), NewTerm(-1, y)}), LpConstraintLE, 1)
+	f, err = unbounded.ParametricObjective(map[string]float64{"x": 1}, -1, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	checkSegments(t, f, [][4]float64{{-1, 0, 0, 0}, {0, 1, 0, 1}, {1, 2, math.Inf(1), 0}})
+	if f.Segments[2].Status != LpStatusUnbounded {
+		t.Errorf("Expected the sweep to end unbounded, got %v", f.Segments[2].Status)
+	}
+}
+
+func TestParametricErrors(t *testing.T) {
+	lp, variables := newApplesProgram()
+	if _, err := lp.ParametricRHS(map[string]float64{"sunlight": 1}, 0, 1); err == nil || !strings.Contains(err.Error(), `"sunlight"`) {
+		t.Errorf("Expected an error for an unknown constraint, got %v", err)
+	}
+	if _, err := lp.ParametricObjective(map[string]float64{"Cherries": 1}, 0, 1); err == nil || !strings.Contains(err.Error(), `"Cherries"`) {
+		t.Errorf("Expected an error for an unknown variable, got %v", err)
+	}
+	for _, option := range []SolverOption{WithPresolve(), WithScaling(), WithExactArithmetic()} {
+		if _, err := lp.ParametricRHS(map[string]float64{"land": 1}, 0, 1, option); err == nil {
+			t.Errorf("Expected an error for an option the sweep cannot honour")
+		}
+		if _, err := lp.ParametricObjective(map[string]float64{"Apples": 1}, 0, 1, option); err == nil {
+			t.Errorf("Expected an error for an option the sweep cannot honour")
+		}
+	}
+
+	lp.AddSecondaryObjective("fewer apples", LpMinimise, NewExpression([]LpTerm{NewTerm(1, variables[0])}))
+	if _, err := lp.ParametricRHS(map[string]float64{"land": 1}, 0, 1); err == nil {
+		t.Errorf("Expected an error for a secondary objective")
+	}
+	if _, err := lp.ParametricObjective(map[string]float64{"Apples": 1}, 0, 1); err == nil {
+		t.Errorf("Expected an error for a secondary objective")
+	}
+}
+
+/* *********************************************************************************************************************
+Duality
+********************************************************************************************************************* */
+
+func TestDual(t *testing.T) {
+	lp, _ := newApplesProgram()
+	dual := lp.Dual()
+
+	expected := "Min: 16 * y_water + 12 * y_land\n\t2 * y_water + 3 * y_land >= 7\n\t4 * y_water + 2 * y_land >= 6"
+	if dual.String() != expected {
+		t.Errorf("Expected\n%v\ngot\n%v", expected, dual.String())
+	}
+	if dual.Constraints[0].Name != "Apples" || dual.Constraints[1].Name != "Bananas" {
+		t.Errorf("Expected a dual constraint for each variable, got %v", dual.Constraints)
+	}
+
+	primal, result := lp.Solve(), dual.Solve()
+	if err := CheckStrongDuality(primal, result); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	y, _ := dual.Variable("y_water")
+	if result.Value(y) != primal.Dual("water") {
+		t.Errorf("Expected y_water to be the shadow price of water, got %v", result.Value(y))
+	}
+}
+
+func TestDualSignRestrictions(t *testing.T) {
+	// A minimisation with <= rows and an equality
+	lp, _ := newExampleProgram()
+	dual := lp.Dual()
+	primal, result := lp.Solve(), dual.Solve()
+	if err := CheckStrongDuality(primal, result); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	values := result.Values()
+	if values["y_second"] != -primal.Dual("second") || values["y_third+"]-values["y_third-"] != primal.Dual("third") {
+		t.Errorf("Expected the dual values to match the shadow prices, got %v", values)
+	}
+
+	// The softened model from TestSetElastic, whose dual bounds the shadow price of xmin by its penalty
+	x, z := NewVariable("x"), NewVariable("y")
+	elastic := NewLinearProgram()
+	elastic.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(1, x), NewTerm(1, z)}))
+	elastic.AddNamedConstraint("total", NewExpression([]LpTerm{NewTerm(1, x), NewTerm(1, z)}), LpConstraintLE, 2)
+	elastic.AddNamedConstraint("xmin", NewExpression([]LpTerm{NewTerm(1, x)}), LpConstraintGE, 3)
+	elastic.SetElastic("xmin", 2)
+	if err := CheckStrongDuality(elastic.Solve(), elastic.Dual().Solve()); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	elastic.Constraint("xmin").Elastic = false
+	if err := CheckStrongDuality(elastic.Solve(), elastic.Dual().Solve()); err != nil {
+		t.Errorf("Unexpected error for an infeasible primal: %v", err)
+	}
+
+	if err := CheckStrongDuality(lp.Solve(), dual.Solve(WithIterationLimit(0))); !errors.Is(err, ErrDualityGap) {
+		t.Errorf("Expected %v, got %v", ErrDualityGap, err)
+	}
+}
+
+/* *********************************************************************************************************************
+Verification
+********************************************************************************************************************* */
+
+func TestVerify(t *testing.T) {
+	for _, build := range []func() (*LinearProgram, []LpVariable){newExampleProgram, newApplesProgram, newPresolveProgram} {
+		lp, _ := build()
+		for _, result := range []*Result{lp.Solve(), lp.Solve(WithPresolve()), lp.Solve(WithScaling()), lp.Solve(WithExactArithmetic())} {
+			verification, err := lp.Verify(result)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !verification.OK(1e-9) {
+				t.Errorf("Expected the result to verify\n%v", verification)
+			}
+		}
+	}
+
+	lp, x := newApplesProgram()
+	result := lp.Solve()
+	result.values[0] += 1
+	verification, _ := lp.Verify(result)
+	if verification.Primal != 3 || verification.Objective != 7 || verification.Complementary == 0 {
+		t.Errorf("Expected the tampered result to fail\n%v", verification)
+	}
+
+	result = lp.Solve()
+	result.duals[0] = -1
+	verification, _ = lp.Verify(result)
+	if verification.Dual != 6 || verification.Primal != 0 {
+		t.Errorf("Expected the tampered duals to fail\n%v", verification)
+	}
+
+	lp.SetCategory(x[0], LpBinary)
+	result = lp.Solve()
+	if verification, err := lp.Verify(result); err != nil || !verification.OK(1e-9) {
+		t.Errorf("Expected the binary result to verify, got %v %v", verification, err)
+	}
+	result.values[0] = 0.5
+	if verification, _ = lp.Verify(result); verification.Integrality != 0.5 {
+		t.Errorf("Expected the fractional binary to fail\n%v", verification)
+	}
+
+	lp.AddConstraint(NewExpression([]LpTerm{NewTerm(1, x[0])}), LpConstraintGE, 2)
+	if _, err := lp.Verify(lp.Solve()); err == nil {
+		t.Errorf("Expected an error verifying a result that is not optimal")
+	}
+
+	lp, _ = newApplesProgram()
+	lp.AddSecondaryObjective("land", LpMinimise, NewExpression([]LpTerm{NewTerm(1, x[0]), NewTerm(1, x[1])}))
+	for _, result := range []*Result{lp.Solve(), lp.Solve(WithObjectiveMode(ObjectiveWeighted))} {
+		if verification, err := lp.Verify(result); err != nil || !verification.OK(1e-6) {
+			t.Errorf("Expected the result with a secondary objective to verify, got %v %v", verification, err)
+		}
+	}
+}
+
+func TestVerifyElastic(t *testing.T) {
+	x, y := NewVariable("x"), NewVariable("y")
+	lp := NewLinearProgram()
+	lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(1, x), NewTerm(1, y)}))
+	lp.AddNamedConstraint("total", NewExpression([]LpTerm{NewTerm(1, x), NewTerm(1, y)}), LpConstraintLE, 2)
+	lp.AddNamedConstraint("xmin", NewExpression([]LpTerm{NewTerm(1, x)}), LpConstraintGE, 3)
+	lp.SetElastic("xmin", 2)
+
+	verification, err := lp.Verify(lp.Solve())
+	if err != nil || !verification.OK(1e-9) {
+		t.Errorf("Expected the elastic result to verify, got %v\n%v", err, verification)
+	}
+}
+
+/* *********************************************************************************************************************
+Constraint Expressions
+********************************************************************************************************************* */
+
+func TestAddConstraintExpr(t *testing.T) {
+	x1, x2, y := NewVariable("x1"), NewVariable("x2"), NewVariable("y")
+	lhs := NewAffineExpression([]LpTerm{NewTerm(1, x1)}, 5)
+	rhs := NewExpression([]LpTerm{NewTerm(2, x2), NewTerm(1, y)})
+
+	lp := NewLinearProgram()
+	lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(1, x2), NewTerm(1, y)})).
+		AddConstraintExpr(lhs, LpConstraintGE, rhs).
+		AddConstraint(NewExpression([]LpTerm{NewTerm(1, x1)}), LpConstraintLE, 3)
+	expected := "Max: 1 * x2 + 1 * y\n\t1 * x1 - 2 * x2 - 1 * y >= -5\n\t1 * x1 <= 3"
+	if lp.String() != expected {
+		t.Errorf("Expected\n%v\ngot\n%v", expected, lp.String())
+	}
+	if lhs.Constant != 5 || rhs.Terms[0].Coefficient != 2 {
+		t.Errorf("Expected the expressions to be left unchanged, got %v and %v", lhs, rhs)
+	}
+
+	result := lp.Solve()
+	if result.Status() != LpStatusOptimal || math.Abs(result.ObjectiveValue()-8) > 0.0001 {
+		t.Errorf("Expected optimal objective 8, got %v %v", result.Status(), result.ObjectiveValue())
+	}
+
+	// A variable on both sides cancels out, leaving y <= 2
+	c := lp.AddNamedConstraintExpr("ymax", NewExpression([]LpTerm{NewTerm(1, x1), NewTerm(1, y)}), LpConstraintLE, NewAffineExpression([]LpTerm{NewTerm(1, x1)}, 2))
+	if len(c.Terms) != 1 || c.Terms[0].Variable.Name != "y" || c.RightHandSide != 2 {
+		t.Errorf("Expected y <= 2, got %v", c)
+	}
+	result = lp.Solve()
+	if math.Abs(result.ObjectiveValue()-5) > 0.0001 || math.Abs(result.Value(x2)-3) > 0.0001 {
+		t.Errorf("Expected objective 5 with x2 = 3, got %v %v", result.ObjectiveValue(), result.Value(x2))
+	}
+
+	// A constant is only moved across by AddConstraintExpr, so a model prints as it was written
+	for _, add := range []func(){
+		func() { lp.AddObjective(LpMaximise, NewAffineExpression([]LpTerm{NewTerm(1, y)}, 1)) },
+		func() { lp.AddConstraint(NewAffineExpression([]LpTerm{NewTerm(1, x1)}, 1), LpConstraintLE, 4) },
+		func() { lp.AddRangeConstraint(NewAffineExpression([]LpTerm{NewTerm(1, x1)}, 1), 0, 4) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected a panic for a constant term")
+				}
+			}()
+			add()
+		}()
+	}
+	if len(lp.Constraints) != 3 {
+		t.Errorf("Expected the rejected constraints to be left out, got %v", lp)
+	}
+}
+
+/* *********************************************************************************************************************
+Range Constraints
+********************************************************************************************************************* */
+
+func TestRangeConstraints(t *testing.T) {
+	x, y := NewVariable("x"), NewVariable("y")
+	build := func(sense LpSense, lower, upper float64, ranged bool) *LinearProgram {
+		gap := NewExpression([]LpTerm{NewTerm(1, x), NewTerm(-1, y)})
+		total := NewExpression([]LpTerm{NewTerm(1, x), NewTerm(1, y)})
+		lp := NewLinearProgram()
+		lp.AddObjective(sense, NewExpression([]LpTerm{NewTerm(2, x), NewTerm(1, y)}))
+		if ranged {
+			lp.AddNamedRangeConstraint("gap", gap, lower, upper)
+		} else {
+			lp.AddNamedConstraint("gap", gap, LpConstraintLE, upper)
+			lp.AddNamedConstraint("gap lower", gap, LpConstraintGE, lower)
+		}
+		lp.AddNamedConstraint("most", total, LpConstraintLE, 5)
+		lp.AddNamedConstraint("least", total, LpConstraintGE, 1)
+		return &lp
+	}
+
+	// Ranges either side of zero, above it and below it, each checked against the same range as a pair of rows
+	for _, bounds := range [][2]float64{{-2, 2}, {2, 3}, {-3, -2}} {
+		for _, sense := range []LpSense{LpMaximise, LpMinimise} {
+			expected := build(sense, bounds[0], bounds[1], false).Solve()
+			dual := expected.Dual("gap") + expected.Dual("gap lower")
+			lp := build(sense, bounds[0], bounds[1], true)
+			for _, options := range [][]SolverOption{nil, {WithExactArithmetic()}, {WithPresolve()}, {WithScaling()}} {
+				result := lp.Solve(options...)
+				if result.Status() != LpStatusOptimal || math.Abs(result.ObjectiveValue()-expected.ObjectiveValue()) > 0.0001 {
+					t.Errorf("%v %v: expected objective %v, got %v %v", sense, bounds, expected.ObjectiveValue(), result.Status(), result.ObjectiveValue())
+					continue
+				}
+				if math.Abs(result.Dual("gap")-dual) > 0.0001 {
+					t.Errorf("%v %v: expected dual %v, got %v", sense, bounds, dual, result.Dual("gap"))
+				}
+				if verification, err := lp.Verify(result); err != nil || !verification.OK(1e-6) {
+					t.Errorf("%v %v: expected the result to verify, got %v %v", sense, bounds, verification, err)
+				}
+			}
+			if err := CheckStrongDuality(lp.Solve(), lp.Dual().Solve()); err != nil {
+				t.Errorf("%v %v: %v", sense, bounds, err)
+			}
+		}
+	}
+
+	lp := build(LpMaximise, 2, 3, true)
+	expected := "Max: 2 * x + 1 * y\n\t2 <= 1 * x - 1 * y <= 3\n\t1 * x + 1 * y <= 5\n\t1 * x + 1 * y >= 1"
+	if lp.String() != expected {
+		t.Errorf("Expected\n%v\ngot\n%v", expected, lp.String())
+	}
+	if stats := lp.Stats(); stats.Ranged != 1 || stats.LessEqual != 1 || !strings.Contains(stats.String(), "1 ranged") {
+		t.Errorf("Expected one ranged row, got %v", stats)
+	}
+	if c := lp.AddNamedRangeConstraint("fixed", NewExpression([]LpTerm{NewTerm(1, x)}), 1, 1); c.ConstraintType != LpConstraintEQ || c.Range != 0 {
+		t.Errorf("Expected equal bounds to give an equality, got %v", c)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic for a range with lower > upper")
+		}
+	}()
+	lp.AddRangeConstraint(NewExpression([]LpTerm{NewTerm(1, y)}), 2, 1)
+}
+
+func TestRangeConstraintSlack(t *testing.T) {
+	// y rises until the slack of the range reaches its upper bound, then x rises with it
+	x, y := NewVariable("x"), NewVariable("y")
+	lp := NewLinearProgram()
+	lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(1, y)})).
+		AddRangeConstraint(NewExpression([]LpTerm{NewTerm(1, x), NewTerm(-1, y)}), -1, 1).
+		AddConstraint(NewExpression([]LpTerm{NewTerm(1, x)}), LpConstraintLE, 2)
+
+	for _, options := range [][]SolverOption{nil, {WithExactArithmetic()}} {
+		result := lp.Solve(options...)
+		if result.Status() != LpStatusOptimal || result.Value(x) != 2 || result.Value(y) != 3 {
+			t.Errorf("Expected x = 2 and y = 3, got %v %v %v", result.Status(), result.Value(x), result.Value(y))
+		}
+		// The lower end holds, and moving the range up by one lowers y by one
+		if result.Dual("c1") != -1 || result.Slack("c1") != 2 || result.Dual("c2") != 1 {
+			t.Errorf("Expected dual -1 and slack 2 on the range, got %v %v", result.Dual("c1"), result.Slack("c1"))
+		}
+	}
+
+	tableau := NewTableau(&lp)
+	for tableau.Pivot() {
+	}
+	if solution := tableau.GetSolution(); solution["s1"] != 2 {
+		t.Errorf("Expected the range slack at its bound of 2, got %v", solution["s1"])
+	}
+}
+
+func TestReadMPSRanges(t *testing.T) {
+	lp, err := ReadMPS(strings.NewReader(`ROWS
+ N  obj
+ L  upper
+ G  lower
+ E  fixed
+COLUMNS
+    x  obj  1  upper  1
+    x  lower  1  fixed  1
+RHS
+    RHS  upper  4  lower  1  fixed  2
+RANGES
+    RNG  upper  3  lower  -2  fixed  -1
+ENDATA`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for name, bounds := range map[string][2]float64{"upper": {1, 4}, "lower": {1, 3}, "fixed": {1, 2}} {
+		if c := lp.Constraint(name); c.lowerBound() != bounds[0] || c.RightHandSide != bounds[1] {
+			t.Errorf("Row %v: expected %v <= x <= %v, got %v", name, bounds[0], bounds[1], c)
+		}
+	}
+}
+
+/* *********************************************************************************************************************
+Piecewise Costs
+********************************************************************************************************************* */
+
+func TestPiecewiseCosts(t *testing.T) {
+	x, y := NewVariable("x"), NewVariable("y")
+
+	// Making x costs 1 each for the first 4, 3 each for the next 4 and 6 each after that, and y costs 4 each, so x is
+	// made up to 8 and y covers the rest
+	lp := NewLinearProgram()
+	lp.AddObjective(LpMinimise, NewExpression([]LpTerm{NewTerm(4, y)})).
+		AddPiecewiseCost(x, []float64{4, 8, math.Inf(1)}, []float64{1, 3, 6}).
+		AddNamedConstraint("demand", NewExpression([]LpTerm{NewTerm(1, x), NewTerm(1, y)}), LpConstraintGE, 10)
+
+	for _, options := range [][]SolverOption{nil, {WithExactArithmetic()}, {WithPresolve()}, {WithScaling()}} {
+		result := lp.Solve(options...)
+		if result.Status() != LpStatusOptimal || math.Abs(result.ObjectiveValue()-24) > 0.0001 {
+			t.Errorf("Expected objective 24, got %v %v", result.Status(), result.ObjectiveValue())
+			continue
+		}
+		if math.Abs(result.Value(x)-8) > 0.0001 || math.Abs(result.Value(y)-2) > 0.0001 || math.Abs(result.Dual("demand")-4) > 0.0001 {
+			t.Errorf("Expected x = 8, y = 2 and dual 4, got %v %v %v", result.Value(x), result.Value(y), result.Dual("demand"))
+		}
+		if len(result.Variables()) != 2 || len(result.Constraints()) != 1 {
+			t.Errorf("Expected the segments to be hidden, got %v %v", result.Variables(), result.Constraints())
+		}
+		if verification, err := lp.Verify(result); err != nil || !verification.OK(1e-6) {
+			t.Errorf("Expected the result to verify, got %v %v", verification, err)
+		}
+	}
+	if result := lp.Solve(WithExactArithmetic()); result.RatObjectiveValue().Cmp(big.NewRat(24, 1)) != 0 {
+		t.Errorf("Expected an exact objective of 24, got %v", result.RatObjectiveValue())
+	}
+	if err := CheckStrongDuality(lp.Solve(), lp.Dual().Solve()); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if _, err := lp.ParametricRHS(map[string]float64{"demand": 1}, 0, 1); err == nil {
+		t.Errorf("Expected parametric analysis to reject piecewise costs")
+	}
+
+	// Stopping x at a breakpoint short of the optimum does not verify
+	result := lp.Solve()
+	result.values[result.variableIndex["x"]], result.values[result.variableIndex["y"]] = 4, 6
+	result.objectiveValue = 28
+	if verification, _ := lp.Verify(result); verification.OK(1e-6) {
+		t.Errorf("Expected x = 4 to fail verification, got\n%v", verification)
+	}
+
+	// Returns that fall from 3 to 0.5 per unit past 2 are worth taking only up to 2 against y at 1
+	lp = NewLinearProgram()
+	lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(1, y)})).
+		AddPiecewiseCost(x, []float64{2, math.Inf(1)}, []float64{3, 0.5}).
+		AddConstraint(NewExpression([]LpTerm{NewTerm(1, x), NewTerm(1, y)}), LpConstraintLE, 5)
+	result = lp.Solve()
+	if result.Status() != LpStatusOptimal || math.Abs(result.ObjectiveValue()-9) > 0.0001 || math.Abs(result.Value(x)-2) > 0.0001 {
+		t.Errorf("Expected objective 9 at x = 2, got %v %v %v", result.Status(), result.ObjectiveValue(), result.Value(x))
+	}
+	if verification, err := lp.Verify(result); err != nil || !verification.OK(1e-6) {
+		t.Errorf("Expected the result to verify, got %v %v", verification, err)
+	}
+
+	// A finite last breakpoint caps the variable
+	lp = NewLinearProgram()
+	lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(1, y)})).
+		AddPiecewiseCost(x, []float64{3}, []float64{2}).
+		AddConstraint(NewExpression([]LpTerm{NewTerm(1, x), NewTerm(1, y)}), LpConstraintLE, 5)
+	if result := lp.Solve(); math.Abs(result.Value(x)-3) > 0.0001 || math.Abs(result.ObjectiveValue()-8) > 0.0001 {
+		t.Errorf("Expected x = 3 with objective 8, got %v %v", result.Value(x), result.ObjectiveValue())
+	}
+}
+
+func TestPiecewiseCostsNonConvex(t *testing.T) {
+	// A volume discount is cheaper per unit further along, so the segments are held in order by an SOS2
+	x, y := NewVariable("x"), NewVariable("y")
+	lp := NewLinearProgram()
+	lp.AddObjective(LpMinimise, NewExpression([]LpTerm{NewTerm(4, y)})).
+		AddPiecewiseCost(x, []float64{10, math.Inf(1)}, []float64{5, 2}).
+		AddNamedConstraint("demand", NewExpression([]LpTerm{NewTerm(1, x), NewTerm(1, y)}), LpConstraintGE, 4)
+
+	// Buying 4 of y beats 4 of x, but 30 of x beats 30 of y once the discount applies
+	for demand, expected := range map[float64][3]float64{4: {0, 4, 16}, 30: {30, 0, 90}} {
+		lp.SetRHS("demand", demand)
+		for _, options := range [][]SolverOption{nil, {WithExactArithmetic()}, {WithPresolve()}} {
+			result := lp.Solve(options...)
+			if result.Status() != LpStatusOptimal || math.Abs(result.ObjectiveValue()-expected[2]) > 0.0001 ||
+				math.Abs(result.Value(x)-expected[0]) > 0.0001 || math.Abs(result.Value(y)-expected[1]) > 0.0001 {
+				t.Errorf("Demand %v: expected x = %v, y = %v and objective %v, got %v %v %v %v", demand, expected[0], expected[1], expected[2], result.Status(), result.Value(x), result.Value(y), result.ObjectiveValue())
+			}
+			if verification, err := lp.Verify(result); err != nil || !verification.OK(1e-6) {
+				t.Errorf("Expected the result to verify, got %v %v", verification, err)
+			}
+		}
+	}
+
+	p := lp.PiecewiseCosts[0]
+	for value, expected := range map[float64]float64{0: 0, 4: 20, 10: 50, 12: 54} {
+		if p.Value(value) != expected || p.ratValue(big.NewRat(int64(value), 1)).Cmp(big.NewRat(int64(expected), 1)) != 0 {
+			t.Errorf("Expected a cost of %v at %v, got %v", expected, value, p.Value(value))
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic for breakpoints out of order")
+		}
+	}()
+	lp.AddPiecewiseCost(x, []float64{4, 2}, []float64{1, 2})
+}
+
+/* *********************************************************************************************************************
+Branch and Bound
+********************************************************************************************************************* */
+
+func TestBranchAndBound(t *testing.T) {
+	// A knapsack whose relaxation takes part of an item
+	x := []LpVariable{NewVariable("x1"), NewVariable("x2"), NewVariable("x3"), NewVariable("x4")}
+	lp := NewLinearProgram()
+	lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(8, x[0]), NewTerm(11, x[1]), NewTerm(6, x[2]), NewTerm(4, x[3])})).
+		AddNamedConstraint("weight", NewExpression([]LpTerm{NewTerm(5, x[0]), NewTerm(7, x[1]), NewTerm(4, x[2]), NewTerm(3, x[3])}), LpConstraintLE, 14)
+	for _, v := range x {
+		lp.SetCategory(v, LpBinary)
+	}
+
+	for _, options := range [][]SolverOption{nil, {WithExactArithmetic()}, {WithPresolve()}, {WithScaling()}} {
+		result := lp.Solve(options...)
+		if result.Status() != LpStatusOptimal || math.Abs(result.ObjectiveValue()-21) > 0.0001 {
+			t.Errorf("Expected objective 21, got %v %v", result.Status(), result.ObjectiveValue())
+			continue
+		}
+		for k, expected := range []float64{0, 1, 1, 1} {
+			if math.Abs(result.Value(x[k])-expected) > 0.0001 {
+				t.Errorf("Expected %v = %v, got %v", x[k].Name, expected, result.Value(x[k]))
+			}
+		}
+		if len(result.Constraints()) != 1 {
+			t.Errorf("Expected the branching rows to be hidden, got %v", result.Constraints())
+		}
+		if verification, err := lp.Verify(result); err != nil || !verification.OK(1e-6) {
+			t.Errorf("Expected the result to verify, got %v %v", verification, err)
+		}
+	}
+	if result := lp.Solve(WithIterationLimit(2)); result.Status() != LpStatusIterationLimit {
+		t.Errorf("Expected %v, got %v", LpStatusIterationLimit, result.Status())
+	}
+
+	// General integers, rounded up past a fractional bound
+	y, z := NewVariable("y"), NewVariable("z")
+	lp = NewLinearProgram()
+	lp.AddObjective(LpMinimise, NewExpression([]LpTerm{NewTerm(3, y), NewTerm(2, z)})).
+		AddConstraint(NewExpression([]LpTerm{NewTerm(1, y), NewTerm(1, z)}), LpConstraintGE, 3.5).
+		SetCategory(y, LpInteger).
+		SetCategory(z, LpInteger)
+	if result := lp.Solve(); result.Status() != LpStatusOptimal || result.Value(z) != 4 || result.ObjectiveValue() != 8 {
+		t.Errorf("Expected z = 4 with objective 8, got %v %v %v", result.Status(), result.Value(z), result.ObjectiveValue())
+	}
+
+	lp.AddConstraint(NewExpression([]LpTerm{NewTerm(1, z)}), LpConstraintLE, 3.5).
+		AddConstraint(NewExpression([]LpTerm{NewTerm(1, z)}), LpConstraintGE, 3.2)
+	if result := lp.Solve(); result.Status() != LpStatusInfeasible || !math.IsNaN(result.ObjectiveValue()) {
+		t.Errorf("Expected %v, got %v %v", LpStatusInfeasible, result.Status(), result.ObjectiveValue())
+	}
+
+	lp = NewLinearProgram()
+	lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(1, y)})).
+		AddConstraint(NewExpression([]LpTerm{NewTerm(1, y), NewTerm(-1, z)}), LpConstraintLE, 0.5).
+		SetCategory(y, LpInteger)
+	if result := lp.Solve(); result.Status() != LpStatusUnbounded {
+		t.Errorf("Expected %v, got %v", LpStatusUnbounded, result.Status())
+	}
+}
+
+/* *********************************************************************************************************************
+Special Ordered Sets
+********************************************************************************************************************* */
+
+func TestSpecialOrderedSets(t *testing.T) {
+	// At most one warehouse is used, the relaxation spreads over all three
+	a, b, c := NewVariable("a"), NewVariable("b"), NewVariable("c")
+	lp := NewLinearProgram()
+	lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(3, a), NewTerm(2, b), NewTerm(4, c)})).
+		AddConstraint(NewExpression([]LpTerm{NewTerm(1, a), NewTerm(1, b), NewTerm(1, c)}), LpConstraintLE, 4).
+		AddConstraint(NewExpression([]LpTerm{NewTerm(1, a)}), LpConstraintLE, 2).
+		AddConstraint(NewExpression([]LpTerm{NewTerm(1, c)}), LpConstraintLE, 1).
+		AddSOS(LpSOS1, []LpVariable{a, b, c}, nil)
+	if s := lp.SpecialOrderedSet("sos1"); s == nil || s.Type != LpSOS1 || len(s.Weights) != 3 || s.Weights[2] != 3 {
+		t.Errorf("Expected sos1 weighted 1, 2, 3, got %v", s)
+	}
+	for _, options := range [][]SolverOption{nil, {WithExactArithmetic()}, {WithPresolve()}} {
+		result := lp.Solve(options...)
+		if result.Status() != LpStatusOptimal || math.Abs(result.ObjectiveValue()-8) > 0.0001 || math.Abs(result.Value(b)-4) > 0.0001 {
+			t.Errorf("Expected b = 4 with objective 8, got %v %v %v", result.Status(), result.Values(), result.ObjectiveValue())
+		}
+		if verification, err := lp.Verify(result); err != nil || !verification.OK(1e-6) {
+			t.Errorf("Expected the result to verify, got %v %v", verification, err)
+		}
+	}
+	result := lp.Solve()
+	result.values[result.variableIndex["c"]] = 0.5
+	if verification, _ := lp.Verify(result); verification.Integrality != 0.5 {
+		t.Errorf("Expected c = 0.5 to break the set\n%v", verification)
+	}
+
+	// Interpolating f between the points 0, 1, 2, 3 with values 0, 1, 2, 6, at x = 2.5 f is 4 rather than the 5 of the
+	// line from the first point to the last
+	x := NewVariable("x")
+	lambda := []LpVariable{NewVariable("l0"), NewVariable("l1"), NewVariable("l2"), NewVariable("l3")}
+	lp = NewLinearProgram()
+	lp.AddObjective(LpMaximise, NewExpression([]LpTerm{NewTerm(1, lambda[1]), NewTerm(2, lambda[2]), NewTerm(6, lambda[3])})).
+		AddConstraint(NewExpression([]LpTerm{NewTerm(1, x), NewTerm(-1, lambda[1]), NewTerm(-2, lambda[2]), NewTerm(-3, lambda[3])}), LpConstraintEQ, 0).
+		AddConstraint(NewExpression([]LpTerm{NewTerm(1, lambda[0]), NewTerm(1, lambda[1]), NewTerm(1, lambda[2]), NewTerm(1, lambda[3])}), LpConstraintEQ, 1).
+		AddConstraint(NewExpression([]LpTerm{NewTerm(1, x)}), LpConstraintLE, 2.5)
+	lp.AddNamedSOS("f", LpSOS2, lambda, []float64{0, 1, 2, 3})
+	result = lp.Solve()
+	if result.Status() != LpStatusOptimal || math.Abs(result.ObjectiveValue()-4) > 0.0001 || math.Abs(result.Value(x)-2.5) > 0.0001 {
+		t.Errorf("Expected f(2.5) = 4, got %v %v %v", result.Status(), result.Value(x), result.ObjectiveValue())
+	}
+	if verification, err := lp.Verify(result); err != nil || !verification.OK(1e-6) {
+		t.Errorf("Expected the result to verify, got %v %v", verification, err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic for weights out of order")
+		}
+	}()
+	lp.AddSOS(LpSOS2, lambda, []float64{0, 2, 1, 3})
 }
 
 func TestGulpRun(t *testing.T) {
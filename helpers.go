@@ -3,8 +3,10 @@ package gulp
 import (
 	"fmt"
 	"math"
+	"strconv"
 )
 
+// String Format the linear program as it was written, one constraint per line
 func (lp *LinearProgram) String() string {
 	stringBuilder := ""
 	if lp.Sense == LpMinimise {
@@ -13,33 +15,15 @@ func (lp *LinearProgram) String() string {
 		stringBuilder += "Max: "
 	}
 
-	for i, v := range lp.ObjectiveFunction.Terms {
-		if i != 0 && lp.ObjectiveFunction.Terms[i].Coefficient >= 0 {
-			stringBuilder += "+ "
-		} else if lp.ObjectiveFunction.Terms[i].Coefficient < 0 {
-			stringBuilder += "- "
-		}
-
-		stringBuilder += fmt.Sprintf("%v * %v", math.Abs(lp.ObjectiveFunction.Terms[i].Coefficient), v.Variable.Name)
-		if i < len(lp.ObjectiveFunction.Terms)-1 {
-			stringBuilder += " "
-		}
-	}
+	stringBuilder += formatTerms(lp.ObjectiveFunction.Terms, plainTerm)
 
 	for _, c := range lp.Constraints {
 		stringBuilder += "\n\t"
-		for i, v := range c.Terms {
-			if i != 0 && c.Terms[i].Coefficient >= 0 {
-				stringBuilder += "+ "
-			} else if c.Terms[i].Coefficient < 0 {
-				stringBuilder += "- "
-			}
-
-			stringBuilder += fmt.Sprintf("%v * %v", math.Abs(c.Terms[i].Coefficient), v.Variable.Name)
-			if i < len(c.Terms)-1 {
-				stringBuilder += " "
-			}
+		if c.Range != 0 {
+			stringBuilder += fmt.Sprintf("%v <= %v <= %v", c.lowerBound(), formatTerms(c.Terms, plainTerm), c.RightHandSide)
+			continue
 		}
+		stringBuilder += formatTerms(c.Terms, plainTerm)
 		switch c.ConstraintType {
 		case LpConstraintLE:
 			stringBuilder += " <= "
@@ -54,3 +38,94 @@ func (lp *LinearProgram) String() string {
 
 	return stringBuilder
 }
+
+// relationSymbol Get the symbol used to print the constraint type
+func relationSymbol(constraintType LpConstraintType) string {
+	switch constraintType {
+	case LpConstraintLE:
+		return "<="
+	case LpConstraintGE:
+		return ">="
+	}
+	return "="
+}
+
+// formatTerms Write the terms as a sum such as "- 6 * x1 + 7 * x2", formatting each term from the magnitude of its
+// coefficient and its variable name
+func formatTerms(terms []LpTerm, format func(coefficient float64, name string) string) string {
+	stringBuilder := ""
+	for i, v := range terms {
+		if i != 0 && v.Coefficient >= 0 {
+			stringBuilder += "+ "
+		} else if v.Coefficient < 0 {
+			stringBuilder += "- "
+		}
+
+		stringBuilder += format(math.Abs(v.Coefficient), v.Variable.Name)
+		if i < len(terms)-1 {
+			stringBuilder += " "
+		}
+	}
+	return stringBuilder
+}
+
+// plainTerm Format a term as "6 * x1"
+func plainTerm(coefficient float64, name string) string {
+	return fmt.Sprintf("%v * %v", coefficient, name)
+}
+
+// clean Round values that are indistinguishable from zero to exactly zero
+func clean(value float64) float64 {
+	if math.Abs(value) < 1e-12 {
+		return 0
+	}
+	return value
+}
+
+// solveLinearSystem Solve the square system a * x = b by Gaussian elimination with partial pivoting, returning false
+// if the system is singular. Neither argument is modified.
+func solveLinearSystem(a [][]float64, b []float64) ([]float64, bool) {
+	n := len(b)
+	m := make([][]float64, n)
+	for i := range a {
+		m[i] = append(append(make([]float64, 0, n+1), a[i]...), b[i])
+	}
+
+	for col := 0; col < n; col++ {
+		best := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(m[row][col]) > math.Abs(m[best][col]) {
+				best = row
+			}
+		}
+		if math.Abs(m[best][col]) < 1e-12 {
+			return nil, false
+		}
+		m[col], m[best] = m[best], m[col]
+
+		for row := col + 1; row < n; row++ {
+			factor := m[row][col] / m[col][col]
+			for k := col; k <= n; k++ {
+				m[row][k] -= factor * m[col][k]
+			}
+		}
+	}
+
+	x := make([]float64, n)
+	for row := n - 1; row >= 0; row-- {
+		val := m[row][n]
+		for k := row + 1; k < n; k++ {
+			val -= m[row][k] * x[k]
+		}
+		x[row] = val / m[row][row]
+	}
+	return x, true
+}
+
+// formatNumber Format a value for reports, to ten significant figures so round-off noise does not show
+func formatNumber(value float64) string {
+	if value == 0 {
+		return "0"
+	}
+	return strconv.FormatFloat(value, 'g', 10, 64)
+}
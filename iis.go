@@ -0,0 +1,115 @@
+package gulp
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrFeasible Returned by ComputeIIS when the linear program is feasible, so there is no conflict to explain
+var ErrFeasible = errors.New("linear program is feasible")
+
+// IIS An irreducible infeasible subsystem: constraints and bounds that cannot all hold at once, although they can if
+// any one of them is dropped
+type IIS struct {
+	Constraints []string // Constraint names, in model order
+	Bounds      []string // Variables whose x >= 0 bound is part of the conflict, in model order
+}
+
+func (iis *IIS) String() string {
+	sb := strings.Builder{}
+	for _, name := range iis.Constraints {
+		sb.WriteString(name + "\n")
+	}
+	for _, name := range iis.Bounds {
+		sb.WriteString(name + " >= 0\n")
+	}
+	return sb.String()
+}
+
+// ComputeIIS Find a minimal set of constraints and non-negativity bounds that are jointly infeasible. The deletion
+// filter drops each constraint and bound in turn, keeping it dropped if the rest are still infeasible, so it takes one
+// feasibility solve per constraint and variable. Integer categories are ignored. Returns ErrFeasible if the linear
+// program is feasible.
+func (lp *LinearProgram) ComputeIIS() (*IIS, error) {
+	rows := make([]bool, len(lp.Constraints))
+	for i := range rows {
+		rows[i] = true
+	}
+	bounds := make([]bool, len(lp.variables))
+	for j := range bounds {
+		bounds[j] = true
+	}
+
+	if !lp.isInfeasible(rows, bounds) {
+		return nil, ErrFeasible
+	}
+
+	for i := range rows {
+		rows[i] = false
+		rows[i] = !lp.isInfeasible(rows, bounds)
+	}
+	for j := range bounds {
+		bounds[j] = false
+		bounds[j] = !lp.isInfeasible(rows, bounds)
+	}
+
+	iis := &IIS{}
+	for i, c := range lp.Constraints {
+		if rows[i] {
+			iis.Constraints = append(iis.Constraints, c.Name)
+		}
+	}
+	for j, v := range lp.variables {
+		if bounds[j] {
+			iis.Bounds = append(iis.Bounds, v.Name)
+		}
+	}
+	return iis, nil
+}
+
+// isInfeasible Check whether the chosen constraints and non-negativity bounds have no solution
+func (lp *LinearProgram) isInfeasible(rows, bounds []bool) bool {
+	return lp.feasibilityModel(rows, bounds).Solve().Status() == LpStatusInfeasible
+}
+
+// feasibilityModel Build a model with a zero objective and only the chosen constraints. Elastic constraints can always
+// be met, so they are left out. A variable whose bound is dropped is free, and is written as the difference of two
+// non-negative variables.
+func (lp *LinearProgram) feasibilityModel(rows, bounds []bool) *LinearProgram {
+	model := NewLinearProgram()
+	substitutes := make(map[string][]LpTerm, len(lp.variables))
+	var objective []LpTerm
+	for j, v := range lp.variables {
+		if bounds[j] {
+			substitutes[v.Name] = []LpTerm{NewTerm(1, v)}
+			objective = append(objective, NewTerm(0, v))
+			continue
+		}
+		positive, negative := NewVariable(lp.unusedName(v.Name+"+")), NewVariable(lp.unusedName(v.Name+"-"))
+		substitutes[v.Name] = []LpTerm{NewTerm(1, positive), NewTerm(-1, negative)}
+		objective = append(objective, NewTerm(0, positive), NewTerm(0, negative))
+	}
+	model.AddObjective(LpMaximise, NewExpression(objective))
+
+	for i, c := range lp.Constraints {
+		if !rows[i] || c.Elastic {
+			continue
+		}
+		var terms []LpTerm
+		for _, t := range c.Terms {
+			for _, s := range substitutes[t.Variable.Name] {
+				terms = append(terms, NewTerm(t.Coefficient*s.Coefficient, s.Variable))
+			}
+		}
+		model.AddNamedConstraint(c.Name, NewExpression(terms), c.ConstraintType, c.RightHandSide).Range = c.Range
+	}
+	return &model
+}
+
+// unusedName Get the name, with primes appended if needed to avoid a registered variable
+func (lp *LinearProgram) unusedName(name string) string {
+	for lp.VariableIndex(name) >= 0 {
+		name += "'"
+	}
+	return name
+}
@@ -3,107 +3,212 @@ package gulp
 import (
 	"fmt"
 	"math"
+	"time"
 )
 
+// bigM The penalty on artificial variables in the objective, large enough to drive them out of any feasible basis
+const bigM = 1e20
+
+// DefaultIterationLimit The number of iterations after which the simplex method gives up, see WithIterationLimit
+const DefaultIterationLimit = 10000
+
 // LinearProgram The Linear Program
 type LinearProgram struct {
 	ObjectiveFunction LpExpression
 	Sense             LpSense
-	Constraints       []_constraint
-	hiddenSense       LpSense
+	Constraints       []*LpConstraint
+
+	// SecondaryObjectives Objectives to optimise after ObjectiveFunction, in priority order, see AddSecondaryObjective
+	SecondaryObjectives []*LpObjective
 
-	// Solution
-	Solution     map[string]float64
-	OptimalValue float64
-	Status       LpStatus
+	// PiecewiseCosts Piecewise-linear costs added to the objective function, see AddPiecewiseCost
+	PiecewiseCosts []*LpPiecewise
+
+	// SpecialOrderedSets Sets of variables of which only one, or two adjacent, may be non-zero, see AddNamedSOS
+	SpecialOrderedSets []*LpSOS
+
+	// Variable registry
+	variables     []LpVariable
+	variableIndex map[string]int
+	categories    map[string]LpCategory
 }
 
 // NewLinearProgram Create a new Linear Program
 func NewLinearProgram() LinearProgram {
-	lp := LinearProgram{}
-	lp.Status = LpStatusNotSolved
-	return lp
+	return LinearProgram{}
 }
 
-// AddObjective Add an objective to the linear program
+// AddObjective Add an objective to the linear program. The terms are copied, so the expression can be reused.
 func (lp *LinearProgram) AddObjective(sense LpSense, objective LpExpression) *LinearProgram {
-	lp.hiddenSense = sense
-	if sense == LpMinimise {
-		for i := range objective.Terms {
-			objective.Terms[i].Coefficient *= -1
-		}
-		sense = LpMaximise
+	if objective.Constant != 0 {
+		panic("Objective function must not have a constant term")
 	}
+	lp.registerTerms(objective.Terms)
 	lp.Sense = sense
-	lp.ObjectiveFunction = objective
+	lp.ObjectiveFunction = NewExpression(append([]LpTerm{}, objective.Terms...))
 	return lp
 }
 
-// AddConstraint Add a constraint to the linear program
+// userObjective Get a copy of the objective terms as they were given
+func (lp *LinearProgram) userObjective() []LpTerm {
+	return append([]LpTerm{}, lp.ObjectiveFunction.Terms...)
+}
+
+// AddConstraint Add an automatically named constraint to the linear program
 func (lp *LinearProgram) AddConstraint(constraint LpExpression, constraintType LpConstraintType, rightHandSide float64) *LinearProgram {
-	// Panic if objective function is not set
-	if len(lp.ObjectiveFunction.Terms) == 0 {
-		panic("Objective function not set")
+	lp.AddNamedConstraint(lp.nextConstraintName(), constraint, constraintType, rightHandSide)
+	return lp
+}
+
+// AddRangeConstraint Add an automatically named constraint lower <= expression <= upper, see AddNamedRangeConstraint
+func (lp *LinearProgram) AddRangeConstraint(constraint LpExpression, lower, upper float64) *LinearProgram {
+	lp.AddNamedRangeConstraint(lp.nextConstraintName(), constraint, lower, upper)
+	return lp
+}
+
+// AddConstraintExpr Add an automatically named constraint with expressions on both sides, such as
+// x1 + 5 >= 2 x2 + y
+func (lp *LinearProgram) AddConstraintExpr(lhs LpExpression, constraintType LpConstraintType, rhs LpExpression) *LinearProgram {
+	lp.AddNamedConstraintExpr(lp.nextConstraintName(), lhs, constraintType, rhs)
+	return lp
+}
+
+// standardForm Convert the linear program into the form the tableau works on: the objective maximised, and the
+// constraints as equalities with non-negative right hand sides, with the slack and artificial variables this requires.
+// The slack of a range constraint is bounded by the width of the range. The linear program itself is left as it was
+// written.
+func (lp *LinearProgram) standardForm() (LpExpression, []_constraint) {
+	// Every registered variable gets a column, even if it does not appear in the objective. Minimising an objective is
+	// maximising its negation.
+	objective := NewExpression(make([]LpTerm, len(lp.variables)))
+	for i, v := range lp.variables {
+		objective.Terms[i] = NewTerm(0, v)
+	}
+	for _, t := range lp.ObjectiveFunction.Terms {
+		objective.Terms[lp.variableIndex[t.Variable.Name]].Coefficient += float64(lp.Sense) * t.Coefficient
 	}
+	constraints := make([]_constraint, 0, len(lp.Constraints))
 
-	if rightHandSide < 0 {
-		// Multiply the constraint by -1, flip equality sign
-		rightHandSide = math.Abs(rightHandSide)
-		for i := range constraint.Terms {
-			constraint.Terms[i].Coefficient *= -1
+	for i, c := range lp.Constraints {
+		constraintType := c.ConstraintType
+		rightHandSide := c.RightHandSide
+		terms := append([]LpTerm{}, c.Terms...)
+
+		// A range that excludes zero is written from the bound nearer zero, so the slack starts within its range
+		if c.Range != 0 && c.lowerBound() > 0 {
+			constraintType, rightHandSide = LpConstraintGE, c.lowerBound()
 		}
-		constraintType = -constraintType
-	}
 
-	// Add Artificial Variables
-	if constraintType == LpConstraintEQ || constraintType == LpConstraintGE {
-		variable := NewArtificialVariable(fmt.Sprintf("a%d", len(lp.Constraints)+1))
-		constraint.Terms = append(constraint.Terms, NewTerm(1, variable))
-		lp.ObjectiveFunction.Terms = append(lp.ObjectiveFunction.Terms, NewTerm(-1e20, variable))
-	}
+		negated := rightHandSide < 0
+		if negated {
+			// Multiply the constraint by -1, flip equality sign
+			rightHandSide = math.Abs(rightHandSide)
+			for j := range terms {
+				terms[j].Coefficient *= -1
+			}
+			constraintType = -constraintType
+		}
+
+		// Add Artificial Variables
+		if constraintType == LpConstraintEQ || constraintType == LpConstraintGE {
+			variable := NewArtificialVariable(fmt.Sprintf("a%d", i+1))
+			terms = append(terms, NewTerm(1, variable))
+			objective.Terms = append(objective.Terms, NewTerm(-bigM, variable))
+		}
+
+		// Add Slack Variables
+		if constraintType == LpConstraintLE || constraintType == LpConstraintGE {
+			variable := NewSlackVariable(fmt.Sprintf("s%d", i+1))
+			sign := 1.0
+			if constraintType == LpConstraintGE {
+				sign = -1.0
+			}
+			terms = append(terms, NewTerm(sign, variable))
+			objective.Terms = append(objective.Terms, NewTerm(0, variable))
+			constraintType = LpConstraintEQ
+		}
 
-	// Add Slack Variables
-	if constraintType == LpConstraintLE || constraintType == LpConstraintGE {
-		variable := NewSlackVariable(fmt.Sprintf("s%d", len(lp.Constraints)+1))
-		sign := 1.0
-		if constraintType == LpConstraintGE {
-			sign = -1.0
+		// Add the surplus (s1+) and deficit (s1-) of elastic constraints, charged at the penalty
+		if c.Elastic {
+			sign := 1.0
+			if negated {
+				sign = -1.0
+			}
+			if c.ConstraintType != LpConstraintGE {
+				variable := NewSlackVariable(fmt.Sprintf("s%d+", i+1))
+				terms = append(terms, NewTerm(-sign, variable))
+				objective.Terms = append(objective.Terms, NewTerm(-c.Penalty, variable))
+			}
+			if c.ConstraintType != LpConstraintLE {
+				variable := NewSlackVariable(fmt.Sprintf("s%d-", i+1))
+				terms = append(terms, NewTerm(sign, variable))
+				objective.Terms = append(objective.Terms, NewTerm(-c.Penalty, variable))
+			}
 		}
-		constraint.Terms = append(constraint.Terms, NewTerm(sign, variable))
-		lp.ObjectiveFunction.Terms = append(lp.ObjectiveFunction.Terms, NewTerm(0, variable))
-		constraintType = LpConstraintEQ
+
+		constraints = append(constraints, _constraint{
+			ConstraintType: constraintType,
+			Terms:          terms,
+			RightHandSide:  rightHandSide,
+			Negated:        negated,
+			Range:          c.Range,
+		})
 	}
 
-	lp.Constraints = append(lp.Constraints, _constraint{constraintType, constraint.Terms, rightHandSide})
+	return objective, constraints
+}
 
-	return lp
+// Solve Solve the linear program with the simplex method. The linear program is not modified, so it can be solved
+// again after editing, or solved concurrently. Secondary objectives are combined with the objective function as set by
+// WithObjectiveMode. The duals and reduced costs price the objective function when solved lexicographically, and the
+// weighted objective otherwise. Models with integer or binary variables, or special ordered sets, are solved by branch
+// and bound.
+func (lp *LinearProgram) Solve(options ...SolverOption) *Result {
+	return lp.solveWith(newSolverConfig(options))
 }
 
-func (lp *LinearProgram) Solve() *LinearProgram {
-	tableau := NewTableau(lp)
-	for !tableau.IsOptimal() {
-		tableau.Pivot()
+// solveWith Solve the linear program with the given configuration, see Solve
+func (lp *LinearProgram) solveWith(config solverConfig) *Result {
+	if len(lp.PiecewiseCosts) > 0 {
+		return lp.solvePiecewise(config)
 	}
-	lp.OptimalValue = tableau.TableauValue * float64(lp.hiddenSense)
-	lp.Status = LpStatusOptimal
-	solution := tableau.GetSolution()
-	lp.Solution = make(map[string]float64)
-	for _, v := range lp.ObjectiveFunction.Terms {
-		if v.Variable.IsSlack || v.Variable.IsArtificial {
-			continue
-		}
-		lp.Solution[v.Variable.Name] = solution[v.Variable.Name]
+	if len(lp.SecondaryObjectives) > 0 {
+		return lp.solveObjectives(config)
 	}
-
-	return lp
+	if lp.hasIntegerVariables() || len(lp.SpecialOrderedSets) > 0 {
+		return lp.solveBranchAndBound(config)
+	}
+	if config.presolve {
+		return lp.solvePresolved(config)
+	}
+	return lp.solve(config)
 }
 
-func (lp *LinearProgram) PrintSolution() {
-	fmt.Println(lp.Status.String())
-	fmt.Println(lp.OptimalValue)
-	for k, v := range lp.Solution {
-		fmt.Printf("%v: %v\n", k, v)
+// solve Solve the linear program as it stands, without presolve
+func (lp *LinearProgram) solve(config solverConfig) *Result {
+	start := time.Now()
+	if config.scaling != 0 && !config.exact {
+		scaled := lp.Scale(config.scaling)
+		config.scaling = 0
+		result := scaled.Unscale(scaled.Model.solve(config))
+		result.solveTime = time.Since(start)
+		return result
+	}
+
+	objective, constraints := lp.standardForm()
+	tableau := newTableau(objective, constraints, lp.Sense)
+	tableau.tolerance = config.tolerance
+
+	if config.exact {
+		rationalTableau := newRationalTableau(tableau)
+		status, iterations := runSimplex(rationalTableau, config)
+		result := newResult(lp, constraints, rationalTableau.Float(), status, iterations, time.Since(start))
+		result.setExact(lp, constraints, rationalTableau)
+		return result
 	}
+
+	status, iterations := runSimplex(tableau, config)
+	return newResult(lp, constraints, tableau, status, iterations, time.Since(start))
 }
 
 /* #####################################################################################################################
@@ -111,16 +216,22 @@ TO BE MOVED TO SEPARATE FILES, SOME OF THE STRUCTS ARE PRIVATE
 ##################################################################################################################### */
 
 type LpExpression struct {
-	Terms []LpTerm
+	Terms    []LpTerm
+	Constant float64 // Only allowed on either side of AddConstraintExpr, which moves it to the right hand side
 }
 
 func NewExpression(terms []LpTerm) LpExpression {
-	return LpExpression{terms}
+	return LpExpression{Terms: terms}
+}
+
+// NewAffineExpression Create an expression with a constant term, such as 2 x + y + 5
+func NewAffineExpression(terms []LpTerm, constant float64) LpExpression {
+	return LpExpression{Terms: terms, Constant: constant}
 }
 
 type LpTerm struct {
 	Coefficient float64
-	Variable    LpVariable // These get added to the variable list in the LinearProgram??
+	Variable    LpVariable // Registered with the LinearProgram when the term is added to it
 }
 
 func NewTerm(coefficient float64, variable LpVariable) LpTerm {
@@ -146,17 +257,20 @@ func NewArtificialVariable(name string) LpVariable {
 	return LpVariable{name, 0, false, true}
 }
 
+// _constraint A constraint in standard form, as consumed by the tableau
 type _constraint struct {
 	ConstraintType LpConstraintType
 	Terms          []LpTerm
 	RightHandSide  float64
+	Negated        bool    // The constraint was multiplied by -1 to make the right hand side non-negative
+	Range          float64 // The upper bound on the slack variable of a range constraint
 }
 
 /* #####################################################################################################################
 TO BE MOVED TO SEPARATE FILES
 ##################################################################################################################### */
 
-// LpCategory Note that this is currently not used
+// LpCategory Whether a variable is continuous, integer or binary, see SetCategory
 type LpCategory string
 
 const (
@@ -185,6 +299,8 @@ const (
 	LpStatusUnbounded      = LpStatus(3)
 	LpStatusUndefined      = LpStatus(4)
 	LpStatusNotImplemented = LpStatus(5)
+	LpStatusIterationLimit = LpStatus(6)
+	LpStatusTimeLimit      = LpStatus(7)
 )
 
 var LpStatusMap = map[LpStatus]string{
@@ -194,10 +310,12 @@ var LpStatusMap = map[LpStatus]string{
 	LpStatusUnbounded:      "Unbounded",
 	LpStatusUndefined:      "Undefined",
 	LpStatusNotImplemented: "Not Implemented",
+	LpStatusIterationLimit: "Iteration Limit",
+	LpStatusTimeLimit:      "Time Limit",
 }
 
-func (s *LpStatus) String() string {
-	return LpStatusMap[*s]
+func (s LpStatus) String() string {
+	return LpStatusMap[s]
 }
 
 type LpConstraintType int
@@ -0,0 +1,307 @@
+package gulp
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+)
+
+// ObjectiveMode How Solve combines the objective function with the secondary objectives, see WithObjectiveMode
+type ObjectiveMode int
+
+const (
+	// ObjectiveLexicographic Optimise each objective in priority order, holding every earlier objective at its optimal
+	// value. This is the default.
+	ObjectiveLexicographic = ObjectiveMode(0)
+	// ObjectiveWeighted Optimise the objective function plus the weighted sum of the secondary objectives in one solve
+	ObjectiveWeighted = ObjectiveMode(1)
+)
+
+// LpObjective A secondary objective, optimised after the objective function, see AddSecondaryObjective
+type LpObjective struct {
+	Name   string
+	Sense  LpSense
+	Terms  []LpTerm
+	Weight float64 // Used by ObjectiveWeighted, relative to a weight of one on the objective function
+}
+
+// WithObjectiveMode Choose how the secondary objectives are combined with the objective function
+func WithObjectiveMode(mode ObjectiveMode) SolverOption {
+	return func(config *solverConfig) {
+		config.objectiveMode = mode
+	}
+}
+
+// AddSecondaryObjective Add an objective to optimise after the objective function and any secondary objectives added
+// before it, with a weight of one. Result.SecondaryObjectiveValue gives its value in the solution.
+func (lp *LinearProgram) AddSecondaryObjective(name string, sense LpSense, objective LpExpression) *LpObjective {
+	if len(lp.ObjectiveFunction.Terms) == 0 {
+		panic("Objective function not set")
+	}
+	if name == "" {
+		panic("Objective name must not be empty")
+	}
+	if lp.SecondaryObjective(name) != nil {
+		panic(fmt.Sprintf("Objective %q already exists", name))
+	}
+	if objective.Constant != 0 {
+		panic(fmt.Sprintf("Objective %q must not have a constant term", name))
+	}
+
+	lp.registerTerms(objective.Terms)
+	o := &LpObjective{
+		Name:   name,
+		Sense:  sense,
+		Terms:  append([]LpTerm{}, objective.Terms...),
+		Weight: 1,
+	}
+	lp.SecondaryObjectives = append(lp.SecondaryObjectives, o)
+	return o
+}
+
+// SecondaryObjective Look up a secondary objective by name, returning nil if there is no such objective
+func (lp *LinearProgram) SecondaryObjective(name string) *LpObjective {
+	for _, o := range lp.SecondaryObjectives {
+		if o.Name == name {
+			return o
+		}
+	}
+	return nil
+}
+
+// solveObjectives Solve a linear program with secondary objectives. Each solve is of a copy of the linear program in
+// which the surplus and deficit of elastic constraints are ordinary variables, so the penalties on the objective
+// function can be held in place along with its value.
+func (lp *LinearProgram) solveObjectives(config solverConfig) *Result {
+	start := time.Now()
+	solve := func(model *LinearProgram) *Result {
+		return model.solveWith(config)
+	}
+
+	rows, penalties := lp.explicitElasticRows()
+	primary := append(lp.userObjective(), penalties...)
+
+	var result *Result
+	iterations := 0
+	if config.objectiveMode == ObjectiveWeighted {
+		terms := primary
+		for _, o := range lp.SecondaryObjectives {
+			// Objectives in the opposite sense count against the objective function
+			weight := o.Weight * float64(o.Sense) * float64(lp.Sense)
+			for _, t := range o.Terms {
+				terms = append(terms, NewTerm(weight*t.Coefficient, t.Variable))
+			}
+		}
+		result = solve(lp.objectiveModel(lp.Sense, terms, rows, nil))
+		iterations = result.iterations
+	} else {
+		var held []*LpConstraint
+		var optima []*Result
+		relaxed := false
+		stages := append([]*LpObjective{{Sense: lp.Sense, Terms: primary}}, lp.SecondaryObjectives...)
+		for k := 0; k < len(stages); k++ {
+			model := lp.objectiveModel(stages[k].Sense, stages[k].Terms, rows, held)
+			result = solve(model)
+			iterations += result.iterations
+			if result.status == LpStatusInfeasible && len(held) > 0 && !relaxed {
+				// Round-off can leave a held optimum just out of reach, so they are all relaxed by the tolerance and
+				// the stage is solved again
+				relaxed = true
+				for i := range held {
+					held[i] = holdRow(held[i].Name, stages[i], optima[i], config.tolerance)
+				}
+				k--
+				continue
+			}
+			if result.status != LpStatusOptimal {
+				break
+			}
+			optima = append(optima, result)
+			held = append(held, holdRow(model.nextConstraintName(), stages[k], result, 0))
+		}
+		if result.status == LpStatusOptimal {
+			result.priceWith(optima[0])
+		}
+	}
+
+	result.restrict(lp)
+	result.iterations = iterations
+	result.solveTime = time.Since(start)
+	return result
+}
+
+// holdRow Build the row that holds an objective at the optimum of its stage. An exact optimum p/q is held as q times
+// the objective against p while every number of that row is a float64. Otherwise the optimum is held as a float64,
+// relaxed by the given share of its size.
+func holdRow(name string, o *LpObjective, result *Result, relax float64) *LpConstraint {
+	row := &LpConstraint{Name: name, ConstraintType: LpConstraintGE, Terms: o.Terms, RightHandSide: result.objectiveValue}
+	if o.Sense == LpMinimise {
+		row.ConstraintType = LpConstraintLE
+	}
+
+	if value := result.exactObjectiveValue; value != nil {
+		denominator := new(big.Rat).SetInt(value.Denom())
+		terms := make([]LpTerm, len(o.Terms))
+		exact := true
+		for k, t := range o.Terms {
+			coefficient, ok := new(big.Rat).Mul(ratFromFloat(t.Coefficient), denominator).Float64()
+			terms[k], exact = NewTerm(coefficient, t.Variable), exact && ok
+		}
+		numerator, ok := new(big.Rat).SetInt(value.Num()).Float64()
+		if exact && ok {
+			row.Terms, row.RightHandSide = terms, numerator
+			return row
+		}
+	}
+	row.RightHandSide -= float64(o.Sense) * relax * math.Max(1, math.Abs(row.RightHandSide))
+	return row
+}
+
+// priceWith Replace the duals and reduced costs of the last stage of a lexicographic solve, which price its own
+// objective against the held rows, with those of the first stage, which price the objective function. Any optimal
+// duals of a linear program are complementary to all of its optimal solutions, so they hold at the final solution too.
+func (r *Result) priceWith(first *Result) {
+	copy(r.duals, first.duals)
+	copy(r.reducedCosts, first.reducedCosts)
+	if r.exactDuals != nil && first.exactDuals != nil {
+		copy(r.exactDuals, first.exactDuals)
+		copy(r.exactReducedCosts, first.exactReducedCosts)
+	}
+}
+
+// explicitElasticRows Copy the constraints with the surplus and deficit of each elastic constraint added as ordinary
+// variables, returning the rows and the penalty terms to add to the objective function
+func (lp *LinearProgram) explicitElasticRows() ([]*LpConstraint, []LpTerm) {
+	rows := make([]*LpConstraint, len(lp.Constraints))
+	var penalties []LpTerm
+	for i, c := range lp.Constraints {
+		rows[i] = &LpConstraint{Name: c.Name, ConstraintType: c.ConstraintType, Terms: append([]LpTerm{}, c.Terms...), RightHandSide: c.RightHandSide, Range: c.Range}
+		if !c.Elastic {
+			continue
+		}
+		// Penalties count against the objective, whichever the sense
+		penalty := -float64(lp.Sense) * c.Penalty
+		if c.ConstraintType != LpConstraintGE {
+			surplus := NewVariable(lp.unusedName("surplus_" + c.Name))
+			rows[i].Terms = append(rows[i].Terms, NewTerm(-1, surplus))
+			penalties = append(penalties, NewTerm(penalty, surplus))
+		}
+		if c.ConstraintType != LpConstraintLE {
+			deficit := NewVariable(lp.unusedName("deficit_" + c.Name))
+			rows[i].Terms = append(rows[i].Terms, NewTerm(1, deficit))
+			penalties = append(penalties, NewTerm(penalty, deficit))
+		}
+	}
+	return rows, penalties
+}
+
+// objectiveModel Build a linear program over the same variables and sets with the given objective, rows and held
+// objectives
+func (lp *LinearProgram) objectiveModel(sense LpSense, objective []LpTerm, rows, held []*LpConstraint) *LinearProgram {
+	model := NewLinearProgram()
+	model.AddVariable(lp.variables...)
+	model.categories = lp.categories
+	model.AddObjective(sense, NewExpression(append([]LpTerm{}, objective...)))
+	model.SpecialOrderedSets = lp.SpecialOrderedSets
+	for _, c := range append(append([]*LpConstraint{}, rows...), held...) {
+		model.AddNamedConstraint(c.Name, NewExpression(append([]LpTerm{}, c.Terms...)), c.ConstraintType, c.RightHandSide).Range = c.Range
+	}
+	return &model
+}
+
+// restrict Cut a result for an objective or piecewise model down to the variables and constraints of the linear
+// program, and evaluate each of its objectives at the solution
+func (r *Result) restrict(lp *LinearProgram) {
+	n, m := len(lp.variables), len(lp.Constraints)
+	for _, v := range r.variables[n:] {
+		delete(r.variableIndex, v.Name)
+	}
+	for _, name := range r.constraints[m:] {
+		delete(r.constraintIndex, name)
+	}
+	r.variables, r.values, r.reducedCosts = r.variables[:n], r.values[:n], r.reducedCosts[:n]
+	r.constraints, r.activities, r.slacks, r.duals = r.constraints[:m], r.activities[:m], r.slacks[:m], r.duals[:m]
+	r.violations = make([]float64, m)
+	if r.exactValues != nil {
+		r.exactValues, r.exactReducedCosts = r.exactValues[:n], r.exactReducedCosts[:n]
+		r.exactActivities, r.exactSlacks, r.exactDuals = r.exactActivities[:m], r.exactSlacks[:m], r.exactDuals[:m]
+	}
+
+	// The rows solved may hold extra terms, such as the surplus and deficit of an elastic constraint, so the activities
+	// are recomputed from the terms of the linear program
+	for i, c := range lp.Constraints {
+		r.activities[i] = r.evaluate(c.Terms)
+		r.slacks[i] = c.RightHandSide - r.activities[i]
+		if r.exactValues != nil {
+			r.exactActivities[i] = new(big.Rat)
+			for _, t := range c.Terms {
+				r.exactActivities[i].Add(r.exactActivities[i], new(big.Rat).Mul(ratFromFloat(t.Coefficient), r.exactValues[r.variableIndex[t.Variable.Name]]))
+			}
+			r.exactSlacks[i] = new(big.Rat).Sub(ratFromFloat(c.RightHandSide), r.exactActivities[i])
+			r.activities[i], _ = r.exactActivities[i].Float64()
+			r.slacks[i], _ = r.exactSlacks[i].Float64()
+		}
+		if c.Elastic {
+			r.violations[i] = violation(r.activities[i], c.ConstraintType, c.RightHandSide)
+		}
+	}
+
+	r.secondaryObjectives = make([]string, len(lp.SecondaryObjectives))
+	r.secondaryObjectiveValues = make([]float64, len(lp.SecondaryObjectives))
+	for k, o := range lp.SecondaryObjectives {
+		r.secondaryObjectives[k] = o.Name
+		r.secondaryObjectiveValues[k] = r.evaluate(o.Terms)
+	}
+
+	if r.status != LpStatusOptimal {
+		return
+	}
+	r.objectiveValue = r.evaluate(lp.userObjective())
+	for _, p := range lp.PiecewiseCosts {
+		r.objectiveValue += p.Value(r.Value(p.Variable))
+	}
+	for i, c := range lp.Constraints {
+		r.objectiveValue -= float64(lp.Sense) * c.Penalty * r.violations[i]
+	}
+	if r.exactValues != nil {
+		r.exactObjectiveValue = new(big.Rat)
+		for _, t := range lp.userObjective() {
+			r.exactObjectiveValue.Add(r.exactObjectiveValue, new(big.Rat).Mul(ratFromFloat(t.Coefficient), r.exactValues[r.variableIndex[t.Variable.Name]]))
+		}
+		for _, p := range lp.PiecewiseCosts {
+			r.exactObjectiveValue.Add(r.exactObjectiveValue, p.ratValue(r.exactValues[r.variableIndex[p.Variable.Name]]))
+		}
+		for i, c := range lp.Constraints {
+			if r.violations[i] != 0 {
+				penalty := new(big.Rat).Mul(ratFromFloat(float64(lp.Sense)*c.Penalty), ratViolation(r.exactActivities[i], c.ConstraintType, ratFromFloat(c.RightHandSide)))
+				r.exactObjectiveValue.Sub(r.exactObjectiveValue, penalty)
+			}
+		}
+		r.objectiveValue, _ = r.exactObjectiveValue.Float64()
+	}
+}
+
+// evaluate Get the value of a sum of terms at the solution
+func (r *Result) evaluate(terms []LpTerm) float64 {
+	value := 0.0
+	for _, t := range terms {
+		value += t.Coefficient * r.Value(t.Variable)
+	}
+	return value
+}
+
+// ratViolation Get how far value is on the wrong side of value {type} rightHandSide
+func ratViolation(value *big.Rat, constraintType LpConstraintType, rightHandSide *big.Rat) *big.Rat {
+	v := new(big.Rat).Sub(value, rightHandSide)
+	switch constraintType {
+	case LpConstraintGE:
+		v.Neg(v)
+	case LpConstraintEQ:
+		v.Abs(v)
+	}
+	if v.Sign() < 0 {
+		return v.SetInt64(0)
+	}
+	return v
+}
@@ -0,0 +1,324 @@
+package gulp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"text/tabwriter"
+)
+
+// ParametricSegment A piece of the optimal value function over which the optimal basis does not change
+type ParametricSegment struct {
+	From float64
+	To   float64
+
+	// Value The optimal value at From, NaN when infeasible and infinite when unbounded
+	Value float64
+	// Slope The rate the optimal value changes with the parameter
+	Slope float64
+
+	// Status LpStatusOptimal, or LpStatusInfeasible or LpStatusUnbounded for the rest of the sweep after the last
+	// breakpoint
+	Status LpStatus
+	// Basis The basic variables over the segment, in row order
+	Basis []string
+}
+
+// ValueFunction The optimal value of a linear program as a piecewise-linear function of a parameter θ, see
+// ParametricRHS and ParametricObjective
+type ValueFunction struct {
+	Segments []ParametricSegment
+}
+
+// Breakpoints Get the values of the parameter at which the optimal basis changes
+func (f *ValueFunction) Breakpoints() []float64 {
+	var breakpoints []float64
+	for k, s := range f.Segments {
+		if k > 0 {
+			breakpoints = append(breakpoints, s.From)
+		}
+	}
+	return breakpoints
+}
+
+// Value Get the optimal value at theta, NaN outside the sweep or where the linear program is infeasible
+func (f *ValueFunction) Value(theta float64) float64 {
+	for _, s := range f.Segments {
+		if theta < s.From || theta > s.To {
+			continue
+		}
+		if s.Status != LpStatusOptimal {
+			return s.Value
+		}
+		return s.Value + s.Slope*(theta-s.From)
+	}
+	return math.NaN()
+}
+
+func (f *ValueFunction) String() string {
+	sb := strings.Builder{}
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "From\tTo\tValue\tSlope\tStatus\n")
+	for _, s := range f.Segments {
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\n", formatNumber(s.From), formatNumber(s.To), formatNumber(s.Value), formatNumber(s.Slope), s.Status)
+	}
+	_ = tw.Flush()
+	return sb.String()
+}
+
+// add Append a segment, dropping those of zero length left by degenerate pivots
+func (f *ValueFunction) add(s ParametricSegment, to, tolerance float64) {
+	if s.To-s.From <= tolerance && s.To < to && len(f.Segments) > 0 {
+		return
+	}
+	f.Segments = append(f.Segments, s)
+}
+
+// ParametricRHS Sweep the right hand sides along a direction, solving with the right hand side of each named
+// constraint set to its value plus θ times its entry in direction, for θ from from to to. The linear program is solved
+// once at from, and each breakpoint after that takes a dual simplex pivot. The linear program must be optimal at from.
+// Returns an error if a name in direction is not a constraint.
+func (lp *LinearProgram) ParametricRHS(direction map[string]float64, from, to float64, options ...SolverOption) (*ValueFunction, error) {
+	for name := range direction {
+		if lp.Constraint(name) == nil {
+			return nil, fmt.Errorf("parametric analysis found no constraint %q", name)
+		}
+	}
+	model := *lp
+	model.Constraints = make([]*LpConstraint, len(lp.Constraints))
+	for i, c := range lp.Constraints {
+		shifted := *c
+		shifted.RightHandSide += from * direction[c.Name]
+		model.Constraints[i] = &shifted
+	}
+
+	tableau, constraints, config, err := model.parametricStart(from, to, options)
+	if err != nil {
+		return nil, err
+	}
+	d := make([]float64, len(constraints))
+	for i, c := range lp.Constraints {
+		d[i] = direction[c.Name]
+		if constraints[i].Negated {
+			d[i] *= -1
+		}
+	}
+
+	f := &ValueFunction{}
+	sense := float64(lp.Sense)
+	tolerance := tableau.tolerance
+	theta := from
+	for pivots := 0; ; pivots++ {
+		if pivots > config.iterationLimit {
+			return f, fmt.Errorf("parametric analysis stopped after %d pivots at θ = %v", pivots, formatNumber(theta))
+		}
+
+		// The basic variables change at the rate B^-1 d, the first to reach zero leaves at the next breakpoint
+		delta, ok := tableau.basisSolve(constraints, d)
+		if !ok {
+			return f, fmt.Errorf("parametric analysis found a singular basis at θ = %v", formatNumber(theta))
+		}
+		step, row, sign := math.Inf(1), -1, 0.0
+		slope := 0.0
+		for i, v := range delta {
+			slope += tableau.BasisColumn.Values[i] * v
+			var ratio float64
+			switch {
+			case v < -tolerance:
+				ratio = math.Max(tableau.BColumn.Values[i], 0) / -v
+			case v > tolerance && tableau.isArtificial(tableau.BasisNames[i]):
+				// An artificial variable may not rise above zero
+				ratio = 0
+			default:
+				continue
+			}
+			if ratio < step {
+				step, row, sign = ratio, i, math.Copysign(1, v)
+			}
+		}
+
+		end := math.Min(theta+step, to)
+		f.add(ParametricSegment{
+			From:   theta,
+			To:     end,
+			Value:  tableau.TableauValue * sense,
+			Slope:  slope * sense,
+			Status: LpStatusOptimal,
+			Basis:  append([]string{}, tableau.BasisNames...),
+		}, to, tolerance)
+		if end >= to {
+			return f, nil
+		}
+
+		for i, v := range delta {
+			tableau.BColumn.Values[i] = clean(tableau.BColumn.Values[i] + (end-theta)*v)
+		}
+		tableau.update()
+		theta = end
+
+		column := tableau.dualPivotColumn(row, sign)
+		if column < 0 {
+			f.add(ParametricSegment{From: theta, To: to, Value: math.NaN(), Status: LpStatusInfeasible}, to, tolerance)
+			return f, nil
+		}
+		tableau.pivotOn(row, column)
+	}
+}
+
+// ParametricObjective Sweep the objective along a direction, solving with the objective coefficient of each named
+// variable set to its value plus θ times its entry in direction, for θ from from to to. The linear program is solved
+// once at from, and each breakpoint after that takes a primal simplex pivot. The linear program must be optimal at
+// from. Returns an error if a name in direction is not a variable.
+func (lp *LinearProgram) ParametricObjective(direction map[string]float64, from, to float64, options ...SolverOption) (*ValueFunction, error) {
+	model := *lp
+	terms := lp.userObjective()
+	for name, v := range direction {
+		variable, ok := lp.Variable(name)
+		if !ok {
+			return nil, fmt.Errorf("parametric analysis found no variable %q", name)
+		}
+		terms = append(terms, NewTerm(from*v, variable))
+	}
+	model.ObjectiveFunction = NewExpression(terms)
+
+	tableau, _, config, err := model.parametricStart(from, to, options)
+	if err != nil {
+		return nil, err
+	}
+	// The tableau maximises, so the direction is turned the same way
+	sense := float64(lp.Sense)
+	d := make([]float64, len(tableau.NamesRow))
+	for name, v := range direction {
+		d[tableau.ColumnIndex(name)] = v * sense
+	}
+
+	f := &ValueFunction{}
+	tolerance := tableau.tolerance
+	theta := from
+	for pivots := 0; ; pivots++ {
+		if pivots > config.iterationLimit {
+			return f, fmt.Errorf("parametric analysis stopped after %d pivots at θ = %v", pivots, formatNumber(theta))
+		}
+
+		// The C-Z entries change at the rate gamma, the first to reach zero enters at the next breakpoint
+		basic := make([]float64, len(tableau.BasisNames))
+		slope := 0.0
+		for i, name := range tableau.BasisNames {
+			basic[i] = d[tableau.ColumnIndex(name)]
+			slope += basic[i] * tableau.BColumn.Values[i]
+		}
+		step, column := math.Inf(1), -1
+		for j, name := range tableau.NamesRow {
+			if tableau.isArtificial(name) {
+				continue
+			}
+			gamma := d[j]
+			for i, row := range tableau.ConstraintRows {
+				gamma -= basic[i] * row.Values[j]
+			}
+			if gamma > tolerance {
+				if ratio := math.Max(-tableau.CZRow.Values[j], 0) / gamma; ratio < step {
+					step, column = ratio, j
+				}
+			}
+		}
+
+		end := math.Min(theta+step, to)
+		f.add(ParametricSegment{
+			From:   theta,
+			To:     end,
+			Value:  tableau.TableauValue * sense,
+			Slope:  slope * sense,
+			Status: LpStatusOptimal,
+			Basis:  append([]string{}, tableau.BasisNames...),
+		}, to, tolerance)
+		if end >= to {
+			return f, nil
+		}
+
+		for j, v := range d {
+			tableau.ObjectiveRow.Values[j] += (end - theta) * v
+		}
+		for i, name := range tableau.BasisNames {
+			tableau.BasisColumn.Values[i] = tableau.ObjectiveRow.Values[tableau.ColumnIndex(name)]
+		}
+		tableau.update()
+		theta = end
+
+		row, ok := tableau.pivotRow(column)
+		if !ok {
+			f.add(ParametricSegment{From: theta, To: to, Value: math.Inf(1) * sense, Status: LpStatusUnbounded}, to, tolerance)
+			return f, nil
+		}
+		tableau.pivotOn(row, column)
+	}
+}
+
+// parametricStart Solve the linear program at the start of a sweep. The sweep works on the float tableau of the
+// original model, so secondary objectives and the presolve, scaling and exact arithmetic options are rejected rather
+// than ignored.
+func (lp *LinearProgram) parametricStart(from, to float64, options []SolverOption) (*Tableau, []_constraint, solverConfig, error) {
+	config := newSolverConfig(options)
+	if to < from {
+		return nil, nil, config, fmt.Errorf("parametric analysis needs from <= to, got %v and %v", formatNumber(from), formatNumber(to))
+	}
+	if lp.hasIntegerVariables() || len(lp.SpecialOrderedSets) > 0 {
+		return nil, nil, config, errors.New("parametric analysis does not support integer or binary variables, or special ordered sets")
+	}
+	if len(lp.PiecewiseCosts) > 0 {
+		return nil, nil, config, errors.New("parametric analysis does not support piecewise costs")
+	}
+	if len(lp.SecondaryObjectives) > 0 {
+		return nil, nil, config, errors.New("parametric analysis does not support secondary objectives")
+	}
+	if config.presolve || config.scaling != 0 || config.exact {
+		return nil, nil, config, errors.New("parametric analysis does not support presolve, scaling or exact arithmetic")
+	}
+	for _, c := range lp.Constraints {
+		if c.Range != 0 {
+			return nil, nil, config, fmt.Errorf("parametric analysis does not support range constraints, got %q", c.Name)
+		}
+	}
+
+	objective, constraints := lp.standardForm()
+	tableau := newTableau(objective, constraints, lp.Sense)
+	tableau.tolerance = config.tolerance
+	if status, _ := runSimplex(tableau, config); status != LpStatusOptimal {
+		return nil, nil, config, fmt.Errorf("parametric analysis needs an optimal solution at θ = %v, got %v", formatNumber(from), status)
+	}
+	return tableau, constraints, config, nil
+}
+
+// basisSolve Solve B x = b for the basis of the standard form rows
+func (t *Tableau) basisSolve(constraints []_constraint, b []float64) ([]float64, bool) {
+	n := len(constraints)
+	basis := make([][]float64, n)
+	for i, c := range constraints {
+		basis[i] = make([]float64, n)
+		for _, term := range c.Terms {
+			for k, name := range t.BasisNames {
+				if name == term.Variable.Name {
+					basis[i][k] += term.Coefficient
+				}
+			}
+		}
+	}
+	return solveLinearSystem(basis, b)
+}
+
+// dualPivotColumn Find the column to replace the variable in the given row by the dual ratio test, keeping the tableau
+// optimal. The sign is -1 when the basic variable is falling below zero and +1 when it must not rise above it. Returns
+// -1 if no column can enter, in which case the problem is infeasible.
+func (t *Tableau) dualPivotColumn(row int, sign float64) int {
+	column, best := -1, math.Inf(1)
+	for j, a := range t.ConstraintRows[row].Values {
+		if sign*a <= t.tolerance || t.isArtificial(t.NamesRow[j]) {
+			continue
+		}
+		if ratio := math.Abs(t.CZRow.Values[j] / a); ratio < best {
+			column, best = j, ratio
+		}
+	}
+	return column
+}
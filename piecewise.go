@@ -0,0 +1,206 @@
+package gulp
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+)
+
+// LpPiecewise A piecewise-linear cost on a variable, added to the objective function, see AddPiecewiseCost
+type LpPiecewise struct {
+	Variable LpVariable
+	// Breakpoints The end of each segment, in increasing order. The first segment starts at zero, and the last may run
+	// to +Inf.
+	Breakpoints []float64
+	// Slopes The cost per unit along each segment
+	Slopes []float64
+}
+
+// AddPiecewiseCost Add a piecewise-linear cost of the variable to the objective function. The cost is zero at zero and
+// rises by Slopes[k] per unit up to Breakpoints[k], so a variable with a finite last breakpoint cannot pass it. Solve
+// expands the cost into a variable per segment, each bounded by the width of its segment, when the cost fits the sense:
+// convex with rising slopes when minimising or concave with falling slopes when maximising. Other costs, such as volume
+// discounts, are expanded into weights on the breakpoints held in an SOS2, and solved by branch and bound.
+func (lp *LinearProgram) AddPiecewiseCost(variable LpVariable, breakpoints, slopes []float64) *LinearProgram {
+	if len(lp.ObjectiveFunction.Terms) == 0 {
+		panic("Objective function not set")
+	}
+	if len(breakpoints) == 0 || len(breakpoints) != len(slopes) {
+		panic(fmt.Sprintf("Variable %q: piecewise cost needs one slope per breakpoint, got %d and %d", variable.Name, len(breakpoints), len(slopes)))
+	}
+	start := 0.0
+	for k, end := range breakpoints {
+		if !(end > start) || (math.IsInf(end, 1) && k < len(breakpoints)-1) {
+			panic(fmt.Sprintf("Variable %q: breakpoints must be positive and increasing, only the last may be +Inf, got %v", variable.Name, breakpoints))
+		}
+		if math.IsNaN(slopes[k]) || math.IsInf(slopes[k], 0) {
+			panic(fmt.Sprintf("Variable %q: slopes must be finite, got %v", variable.Name, slopes))
+		}
+		start = end
+	}
+
+	lp.registerVariable(variable)
+	lp.PiecewiseCosts = append(lp.PiecewiseCosts, &LpPiecewise{
+		Variable:    variable,
+		Breakpoints: append([]float64{}, breakpoints...),
+		Slopes:      append([]float64{}, slopes...),
+	})
+	return lp
+}
+
+// Value Get the cost at x, continuing along the last slope past the last breakpoint
+func (p *LpPiecewise) Value(x float64) float64 {
+	value, start := 0.0, 0.0
+	for k, end := range p.Breakpoints {
+		if k == len(p.Breakpoints)-1 {
+			end = math.Inf(1)
+		}
+		value += p.Slopes[k] * math.Max(math.Min(x, end)-start, 0)
+		start = end
+	}
+	return value
+}
+
+// ratValue Get the cost at x exactly, see Value
+func (p *LpPiecewise) ratValue(x *big.Rat) *big.Rat {
+	value, start := new(big.Rat), new(big.Rat)
+	for k, end := range p.Breakpoints {
+		length := new(big.Rat).Sub(x, start)
+		if k < len(p.Breakpoints)-1 {
+			end := ratFromFloat(end)
+			if x.Cmp(end) > 0 {
+				length.Sub(end, start)
+			}
+			start = end
+		}
+		if length.Sign() > 0 {
+			value.Add(value, length.Mul(length, ratFromFloat(p.Slopes[k])))
+		}
+		if x.Cmp(start) <= 0 {
+			break
+		}
+	}
+	return value
+}
+
+// fits Check that the cost is convex when minimising or concave when maximising, so that the cheapest segments fill
+// first without forcing them to
+func (p *LpPiecewise) fits(sense LpSense) bool {
+	for k := 1; k < len(p.Slopes); k++ {
+		if float64(sense)*(p.Slopes[k]-p.Slopes[k-1]) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// slopesAt Get the slope of the cost just below and just above x, within the default tolerance of a breakpoint.
+// Returns false if x is at a finite last breakpoint, so cannot rise.
+func (p *LpPiecewise) slopesAt(x float64) (float64, float64, bool) {
+	for k, end := range p.Breakpoints {
+		switch {
+		case x < end-DefaultTolerance:
+			return p.Slopes[k], p.Slopes[k], true
+		case x <= end+DefaultTolerance && k < len(p.Breakpoints)-1:
+			return p.Slopes[k], p.Slopes[k+1], true
+		}
+	}
+	last := p.Slopes[len(p.Slopes)-1]
+	return last, last, false
+}
+
+// piecewiseFits Check that every piecewise cost fits the sense of the objective, so needs no branching
+func (lp *LinearProgram) piecewiseFits() bool {
+	for _, p := range lp.PiecewiseCosts {
+		if !p.fits(lp.Sense) {
+			return false
+		}
+	}
+	return true
+}
+
+// piecewiseModel Build a copy of the linear program with each piecewise cost replaced by variables of its own, named
+// after the variable and a number. A cost that fits the sense has a variable per segment, x_1, x_2, ..., each bounded
+// by the width of its segment and charged its slope, with a row keeping the variable equal to their sum. Any other cost
+// has a weight per breakpoint, x_0 for zero, x_1, ..., which sum to one and are charged the cost at their breakpoint,
+// with a row keeping the variable equal to the weighted sum of the breakpoints. An SOS2 over the weights keeps the
+// variable between two adjacent breakpoints, and a last breakpoint of +Inf gives a last variable that runs along the
+// last slope from the breakpoint before.
+func (lp *LinearProgram) piecewiseModel() *LinearProgram {
+	model := NewLinearProgram()
+	model.AddVariable(lp.variables...)
+	for _, v := range lp.variables {
+		if category := lp.Category(v); category != LpContinuous {
+			model.SetCategory(v, category)
+		}
+	}
+
+	objective := lp.userObjective()
+	var rows []*LpConstraint
+	var sets [][]LpVariable
+	for _, p := range lp.PiecewiseCosts {
+		link := []LpTerm{NewTerm(1, p.Variable)}
+		if p.fits(lp.Sense) {
+			start := 0.0
+			for k, end := range p.Breakpoints {
+				segment := NewVariable(model.unusedName(fmt.Sprintf("%v_%d", p.Variable.Name, k+1)))
+				model.AddVariable(segment)
+				objective = append(objective, NewTerm(p.Slopes[k], segment))
+				link = append(link, NewTerm(-1, segment))
+				if !math.IsInf(end, 1) {
+					rows = append(rows, &LpConstraint{ConstraintType: LpConstraintLE, Terms: []LpTerm{NewTerm(1, segment)}, RightHandSide: end - start})
+				}
+				start = end
+			}
+			rows = append(rows, &LpConstraint{ConstraintType: LpConstraintEQ, Terms: link})
+			continue
+		}
+
+		var weights []LpTerm
+		var set []LpVariable
+		for k, point := range append([]float64{0}, p.Breakpoints...) {
+			weight := NewVariable(model.unusedName(fmt.Sprintf("%v_%d", p.Variable.Name, k)))
+			model.AddVariable(weight)
+			set = append(set, weight)
+			if math.IsInf(point, 1) {
+				objective = append(objective, NewTerm(p.Slopes[k-1], weight))
+				link = append(link, NewTerm(-1, weight))
+				continue
+			}
+			objective = append(objective, NewTerm(p.Value(point), weight))
+			link = append(link, NewTerm(-point, weight))
+			weights = append(weights, NewTerm(1, weight))
+		}
+		rows = append(rows,
+			&LpConstraint{ConstraintType: LpConstraintEQ, Terms: link},
+			&LpConstraint{ConstraintType: LpConstraintEQ, Terms: weights, RightHandSide: 1},
+		)
+		sets = append(sets, set)
+	}
+	model.AddObjective(lp.Sense, NewExpression(objective))
+	model.SecondaryObjectives = lp.SecondaryObjectives
+	model.SpecialOrderedSets = append([]*LpSOS{}, lp.SpecialOrderedSets...)
+	for _, set := range sets {
+		model.AddSOS(LpSOS2, set, nil)
+	}
+
+	for _, c := range lp.Constraints {
+		row := *c
+		row.Terms = append([]LpTerm{}, c.Terms...)
+		model.Constraints = append(model.Constraints, &row)
+	}
+	for _, row := range rows {
+		model.AddNamedConstraint(model.nextConstraintName(), NewExpression(row.Terms), row.ConstraintType, row.RightHandSide)
+	}
+	return &model
+}
+
+// solvePiecewise Solve a linear program with piecewise costs through its piecewiseModel
+func (lp *LinearProgram) solvePiecewise(config solverConfig) *Result {
+	start := time.Now()
+	result := lp.piecewiseModel().solveWith(config)
+	result.restrict(lp)
+	result.solveTime = time.Since(start)
+	return result
+}
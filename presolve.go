@@ -0,0 +1,503 @@
+package gulp
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Presolved A linear program reduced by Presolve, with what is needed to map a solution of the reduced model back to
+// the original variables and constraints
+type Presolved struct {
+	// Model The reduced linear program, nil when presolve settled the problem on its own. A variable whose lower bound
+	// was raised above zero is shifted, so in the reduced model it measures the distance above that bound.
+	Model *LinearProgram
+
+	// Status LpStatusInfeasible if presolve proved the problem infeasible, LpStatusOptimal if it fixed every
+	// variable, and LpStatusNotSolved when Model still has to be solved
+	Status LpStatus
+
+	// Reductions A description of each change made to the model, in the order they were made
+	Reductions []string
+
+	// The original model, copied so that later edits to it do not affect Postsolve
+	sense     LpSense
+	variables []LpVariable
+	objective []float64
+	rows      []sparseRow
+
+	// Bounds found from singleton rows, and the row and coefficient each bound came from (-1 for the implicit x >= 0)
+	lower, upper                       []float64
+	lowerRow, upperRow                 []int
+	lowerCoefficient, upperCoefficient []float64
+	fixed                              []bool
+
+	// singletons The row and variable of each singleton row applied as bounds, in the order they were removed
+	singletons [][2]int
+
+	// kept Whether each original row is still a constraint of the reduced model
+	kept []bool
+}
+
+// sparseRow A constraint with its coefficients summed by variable index
+type sparseRow struct {
+	name           string
+	constraintType LpConstraintType
+	coefficients   map[int]float64 // By variable index, with zero coefficients removed
+	rightHandSide  float64
+	elastic        bool
+	penalty        float64
+	width          float64 // The Range of a range constraint
+}
+
+// sparseRows Copy the constraints as sparse rows
+func (lp *LinearProgram) sparseRows() []sparseRow {
+	rows := make([]sparseRow, len(lp.Constraints))
+	for i, c := range lp.Constraints {
+		rows[i] = sparseRow{
+			name:           c.Name,
+			constraintType: c.ConstraintType,
+			coefficients:   make(map[int]float64),
+			rightHandSide:  c.RightHandSide,
+			elastic:        c.Elastic,
+			penalty:        c.Penalty,
+			width:          c.Range,
+		}
+		for _, t := range c.Terms {
+			rows[i].coefficients[lp.variableIndex[t.Variable.Name]] += t.Coefficient
+		}
+		for j, a := range rows[i].coefficients {
+			if a == 0 {
+				delete(rows[i].coefficients, j)
+			}
+		}
+	}
+	return rows
+}
+
+// limits Get the lower and upper limits the row places on its activity when its right hand side is the given value
+func (row sparseRow) limits(rightHandSide float64) (float64, float64) {
+	switch {
+	case row.width != 0:
+		return rightHandSide - row.width, rightHandSide
+	case row.constraintType == LpConstraintLE:
+		return math.Inf(-1), rightHandSide
+	case row.constraintType == LpConstraintGE:
+		return rightHandSide, math.Inf(1)
+	}
+	return rightHandSide, rightHandSide
+}
+
+// WithPresolve Presolve the linear program before solving it, see Presolve
+func WithPresolve() SolverOption {
+	return func(config *solverConfig) {
+		config.presolve = true
+	}
+}
+
+// Presolve Reduce the linear program before it is solved. Empty rows are removed, singleton rows become bounds on their
+// variable, variables whose bounds meet are fixed and substituted out, and duplicated constraints are merged. Elastic
+// constraints are kept as they are, and range constraints are only removed when empty or singletons. Problems these
+// reductions prove infeasible are reported without solving. Solve the reduced Model and pass its Result to Postsolve to
+// get the result for the original linear program.
+func (lp *LinearProgram) Presolve() *Presolved {
+	n := len(lp.variables)
+	p := &Presolved{
+		sense:            lp.Sense,
+		variables:        lp.Variables(),
+		objective:        make([]float64, n),
+		lower:            make([]float64, n),
+		upper:            make([]float64, n),
+		lowerRow:         make([]int, n),
+		upperRow:         make([]int, n),
+		lowerCoefficient: make([]float64, n),
+		upperCoefficient: make([]float64, n),
+		fixed:            make([]bool, n),
+		kept:             make([]bool, len(lp.Constraints)),
+	}
+	for j := range p.variables {
+		p.upper[j] = math.Inf(1)
+		p.lowerRow[j], p.upperRow[j] = -1, -1
+	}
+	for _, t := range lp.userObjective() {
+		p.objective[lp.variableIndex[t.Variable.Name]] += t.Coefficient
+	}
+
+	// Work on copies of the rows, the originals are kept for Postsolve
+	p.rows = lp.sparseRows()
+	coefficients := make([]map[int]float64, len(p.rows))
+	rightHandSides := make([]float64, len(p.rows))
+	for i, row := range p.rows {
+		p.kept[i] = true
+		coefficients[i] = make(map[int]float64, len(row.coefficients))
+		for j, a := range row.coefficients {
+			coefficients[i][j] = a
+		}
+		rightHandSides[i] = row.rightHandSide
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for i, row := range p.rows {
+			if !p.kept[i] || row.elastic {
+				continue
+			}
+			lower, upper := row.limits(rightHandSides[i])
+			switch len(coefficients[i]) {
+			case 0:
+				if lower > DefaultTolerance || upper < -DefaultTolerance {
+					if row.width != 0 {
+						p.infeasible("Row %v reduces to %v <= 0 <= %v", row.name, formatNumber(lower), formatNumber(upper))
+					} else {
+						p.infeasible("Row %v reduces to 0 %v %v", row.name, relationSymbol(row.constraintType), formatNumber(rightHandSides[i]))
+					}
+					return p
+				}
+				p.kept[i] = false
+				p.reduce("Removed empty row %v", row.name)
+				changed = true
+			case 1:
+				for j, a := range coefficients[i] {
+					p.tighten(i, j, a, lower, upper)
+					p.singletons = append(p.singletons, [2]int{i, j})
+				}
+				p.kept[i] = false
+				changed = true
+			}
+		}
+
+		for j, v := range p.variables {
+			if p.fixed[j] {
+				continue
+			}
+			if p.lower[j] > p.upper[j]+DefaultTolerance {
+				p.infeasible("Bounds on %v conflict: %v <= %v <= %v", v.Name, formatNumber(p.lower[j]), v.Name, formatNumber(p.upper[j]))
+				return p
+			}
+			if p.upper[j]-p.lower[j] <= DefaultTolerance {
+				p.fixed[j] = true
+				p.reduce("Fixed %v = %v", v.Name, formatNumber(p.lower[j]))
+				for i := range p.rows {
+					if a, ok := coefficients[i][j]; ok && p.kept[i] {
+						rightHandSides[i] -= a * p.lower[j]
+						delete(coefficients[i], j)
+					}
+				}
+				changed = true
+			}
+		}
+	}
+
+	if !p.removeDuplicates(coefficients, rightHandSides) {
+		return p
+	}
+	p.buildModel(coefficients, rightHandSides)
+	return p
+}
+
+// tighten Apply the singleton row lower <= a * x_j <= upper as bounds on x_j, either limit may be infinite
+func (p *Presolved) tighten(row, j int, a, lower, upper float64) {
+	low, high := lower/a, upper/a
+	if a < 0 {
+		low, high = high, low
+	}
+
+	tightened := false
+	if low > p.lower[j]+DefaultTolerance {
+		p.lower[j], p.lowerRow[j], p.lowerCoefficient[j] = low, row, a
+		tightened = true
+	}
+	if high < p.upper[j]-DefaultTolerance {
+		p.upper[j], p.upperRow[j], p.upperCoefficient[j] = high, row, a
+		tightened = true
+	}
+
+	name := p.variables[j].Name
+	switch {
+	case !tightened:
+		p.reduce("Removed redundant row %v", p.rows[row].name)
+	case low == high:
+		p.reduce("Row %v is a bound: %v = %v", p.rows[row].name, name, formatNumber(low))
+	case math.IsInf(low, -1):
+		p.reduce("Row %v is a bound: %v <= %v", p.rows[row].name, name, formatNumber(high))
+	case math.IsInf(high, 1):
+		p.reduce("Row %v is a bound: %v >= %v", p.rows[row].name, name, formatNumber(low))
+	default:
+		p.reduce("Row %v is a bound: %v <= %v <= %v", p.rows[row].name, formatNumber(low), name, formatNumber(high))
+	}
+}
+
+// removeDuplicates Merge rows with the same coefficients up to a scale factor, keeping the tighter of two
+// inequalities in the same direction. Returns false if two of the rows cannot both hold.
+func (p *Presolved) removeDuplicates(coefficients []map[int]float64, rightHandSides []float64) bool {
+	type normalised struct {
+		row            int
+		constraintType LpConstraintType
+		rightHandSide  float64
+	}
+	normalise := func(i int) (string, normalised) {
+		indices := sortedIndices(coefficients[i])
+		scale := coefficients[i][indices[0]]
+		key := strings.Builder{}
+		for _, j := range indices {
+			key.WriteString(fmt.Sprintf("%d:%.12g ", j, coefficients[i][j]/scale))
+		}
+		constraintType := p.rows[i].constraintType
+		if scale < 0 {
+			constraintType = -constraintType
+		}
+		return key.String(), normalised{i, constraintType, rightHandSides[i] / scale}
+	}
+
+	seen := make(map[string]normalised)
+	for i, row := range p.rows {
+		if !p.kept[i] || row.elastic || row.width != 0 {
+			continue
+		}
+		key, current := normalise(i)
+		previous, ok := seen[key]
+		if !ok {
+			seen[key] = current
+			continue
+		}
+
+		first, second := p.rows[previous.row].name, p.rows[i].name
+		conflict := false
+		drop := -1
+		switch {
+		case previous.constraintType == current.constraintType:
+			switch {
+			case current.constraintType == LpConstraintEQ:
+				conflict = math.Abs(previous.rightHandSide-current.rightHandSide) > DefaultTolerance
+				drop = current.row
+			case float64(current.constraintType)*(current.rightHandSide-previous.rightHandSide) > 0:
+				// The new row is tighter, a larger right hand side for >= or a smaller one for <=
+				drop = previous.row
+				seen[key] = current
+			default:
+				drop = current.row
+			}
+		case previous.constraintType == LpConstraintEQ:
+			conflict = !satisfies(previous.rightHandSide, current.constraintType, current.rightHandSide)
+			drop = current.row
+		case current.constraintType == LpConstraintEQ:
+			conflict = !satisfies(current.rightHandSide, previous.constraintType, previous.rightHandSide)
+			drop = previous.row
+			seen[key] = current
+		default:
+			// Opposite inequalities bound the row from both sides, both are kept
+			lower, upper := previous.rightHandSide, current.rightHandSide
+			if previous.constraintType == LpConstraintLE {
+				lower, upper = upper, lower
+			}
+			conflict = lower > upper+DefaultTolerance
+		}
+
+		if conflict {
+			p.infeasible("Rows %v and %v cannot both hold", first, second)
+			return false
+		}
+		if drop >= 0 {
+			p.kept[drop] = false
+			kept := first
+			if drop == previous.row {
+				kept = second
+			}
+			p.reduce("Removed row %v, a duplicate of %v", p.rows[drop].name, kept)
+		}
+	}
+	return true
+}
+
+// buildModel Build the reduced linear program from the remaining rows, variables and bounds
+func (p *Presolved) buildModel(coefficients []map[int]float64, rightHandSides []float64) {
+	model := NewLinearProgram()
+	var objective []LpTerm
+	for j, v := range p.variables {
+		if !p.fixed[j] {
+			model.AddVariable(v)
+			objective = append(objective, NewTerm(p.objective[j], v))
+		}
+	}
+	if len(objective) == 0 {
+		p.Status = LpStatusOptimal
+		return
+	}
+	model.AddObjective(p.sense, NewExpression(objective))
+
+	for i, row := range p.rows {
+		if !p.kept[i] {
+			continue
+		}
+		var terms []LpTerm
+		rightHandSide := rightHandSides[i]
+		for _, j := range sortedIndices(coefficients[i]) {
+			terms = append(terms, NewTerm(coefficients[i][j], p.variables[j]))
+			rightHandSide -= coefficients[i][j] * p.lower[j]
+		}
+		c := model.AddNamedConstraint(row.name, NewExpression(terms), row.constraintType, rightHandSide)
+		c.Elastic, c.Penalty, c.Range = row.elastic, row.penalty, row.width
+	}
+
+	// Finite upper bounds go back in as rows, named after the row the bound came from
+	for j, v := range p.variables {
+		if !p.fixed[j] && !math.IsInf(p.upper[j], 1) {
+			model.AddNamedConstraint(p.rows[p.upperRow[j]].name, NewExpression([]LpTerm{NewTerm(1, v)}), LpConstraintLE, p.upper[j]-p.lower[j])
+		}
+	}
+
+	p.Model = &model
+}
+
+// Postsolve Map the result of solving the reduced Model back to the original linear program. Values are shifted back
+// by the bounds found in presolve, fixed variables take their fixed values, and the duals of rows that became bounds
+// are recovered from the reduced costs, in reverse order of removal. Pass nil when Model is nil.
+func (p *Presolved) Postsolve(reduced *Result) *Result {
+	sense := float64(p.sense)
+	status := p.Status
+	if p.Model != nil {
+		status = reduced.Status()
+	}
+
+	r := &Result{
+		status:          status,
+		variables:       append([]LpVariable{}, p.variables...),
+		variableIndex:   make(map[string]int, len(p.variables)),
+		values:          make([]float64, len(p.variables)),
+		reducedCosts:    make([]float64, len(p.variables)),
+		constraints:     make([]string, len(p.rows)),
+		constraintIndex: make(map[string]int, len(p.rows)),
+		activities:      make([]float64, len(p.rows)),
+		slacks:          make([]float64, len(p.rows)),
+		duals:           make([]float64, len(p.rows)),
+		violations:      make([]float64, len(p.rows)),
+	}
+	if reduced != nil {
+		r.iterations, r.solveTime = reduced.Iterations(), reduced.SolveTime()
+	}
+
+	for j, v := range r.variables {
+		r.variableIndex[v.Name] = j
+		r.values[j] = p.lower[j]
+		if !p.fixed[j] && reduced != nil {
+			r.values[j] += reduced.Value(v)
+		}
+	}
+
+	for i, row := range p.rows {
+		r.constraints[i] = row.name
+		r.constraintIndex[row.name] = i
+		for j, a := range row.coefficients {
+			r.activities[i] += a * r.values[j]
+		}
+		r.slacks[i] = row.rightHandSide - r.activities[i]
+		if row.elastic {
+			r.violations[i] = violation(r.activities[i], row.constraintType, row.rightHandSide)
+		}
+	}
+
+	if status == LpStatusOptimal {
+		// Rows still in the model keep their duals, the rest start at zero. Without a model only elastic rows are kept,
+		// and a broken one is priced at its penalty.
+		for i, row := range p.rows {
+			switch {
+			case !p.kept[i]:
+			case reduced != nil:
+				r.duals[i] = reduced.Dual(row.name)
+			case r.violations[i] > 0 && r.activities[i] > row.rightHandSide:
+				r.duals[i] = sense * row.penalty
+			case r.violations[i] > 0:
+				r.duals[i] = -sense * row.penalty
+			}
+		}
+
+		// What remains of each objective coefficient is the price of the bound holding the variable in place. The
+		// singleton rows are undone in reverse order, so the rows removed after one, which may still hold its variable,
+		// are priced first.
+		for k := len(p.singletons) - 1; k >= 0; k-- {
+			i, j := p.singletons[k][0], p.singletons[k][1]
+			price := p.objective[j]
+			for i, row := range p.rows {
+				price -= r.duals[i] * row.coefficients[j]
+			}
+			switch {
+			case price*sense < -DefaultTolerance && p.lowerRow[j] == i:
+				r.duals[i] = price / p.lowerCoefficient[j]
+			case price*sense > DefaultTolerance && p.upperRow[j] == i:
+				r.duals[i] = price / p.upperCoefficient[j]
+			}
+		}
+
+		for j := range r.variables {
+			r.reducedCosts[j] = p.objective[j]
+			for i, row := range p.rows {
+				r.reducedCosts[j] -= r.duals[i] * row.coefficients[j]
+			}
+			r.reducedCosts[j] = clean(r.reducedCosts[j])
+		}
+	}
+
+	switch status {
+	case LpStatusInfeasible:
+		r.objectiveValue = math.NaN()
+	case LpStatusUnbounded:
+		r.objectiveValue = math.Inf(1) * sense
+	default:
+		for j := range r.variables {
+			r.objectiveValue += p.objective[j] * r.values[j]
+		}
+		// Penalties count against the objective, whichever the sense
+		for i, row := range p.rows {
+			r.objectiveValue -= sense * row.penalty * r.violations[i]
+		}
+	}
+
+	return r
+}
+
+func (p *Presolved) reduce(format string, args ...interface{}) {
+	p.Reductions = append(p.Reductions, fmt.Sprintf(format, args...))
+}
+
+func (p *Presolved) infeasible(format string, args ...interface{}) {
+	p.Status = LpStatusInfeasible
+	p.Model = nil
+	p.reduce(format, args...)
+}
+
+// solvePresolved Presolve the linear program, solve the reduced model and map the result back
+func (lp *LinearProgram) solvePresolved(config solverConfig) *Result {
+	start := time.Now()
+	presolved := lp.Presolve()
+
+	var reduced *Result
+	if presolved.Model != nil {
+		config.presolve = false
+		reduced = presolved.Model.solve(config)
+	}
+	result := presolved.Postsolve(reduced)
+	result.solveTime = time.Since(start)
+	return result
+}
+
+// satisfies Check value {type} rightHandSide within the default tolerance
+func satisfies(value float64, constraintType LpConstraintType, rightHandSide float64) bool {
+	switch constraintType {
+	case LpConstraintLE:
+		return value <= rightHandSide+DefaultTolerance
+	case LpConstraintGE:
+		return value >= rightHandSide-DefaultTolerance
+	}
+	return math.Abs(value-rightHandSide) <= DefaultTolerance
+}
+
+func sortedIndices(coefficients map[int]float64) []int {
+	indices := make([]int, 0, len(coefficients))
+	for j := range coefficients {
+		indices = append(indices, j)
+	}
+	sort.Ints(indices)
+	return indices
+}
@@ -0,0 +1,558 @@
+package gulp
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// RationalTableau A tableau over exact rational numbers, so that small problems are solved without round-off. It
+// mirrors Tableau, and is used by Solve when WithExactArithmetic is given.
+type RationalTableau struct {
+	NamesRow       []string
+	ObjectiveRow   []*big.Rat
+	ConstraintRows [][]*big.Rat
+
+	BasisNames  []string
+	BasisColumn []*big.Rat
+	BColumn     []*big.Rat
+
+	ZRow  []*big.Rat
+	CZRow []*big.Rat
+
+	TableauValue *big.Rat
+
+	Sense LpSense
+
+	Variables []LpVariable
+
+	// Upper bounds on the slacks of range constraints, nil where a column is unbounded, see Tableau
+	upper        []*big.Rat
+	complemented []bool
+}
+
+// WithExactArithmetic Solve over exact rational numbers instead of float64, results can then be read as fractions
+func WithExactArithmetic() SolverOption {
+	return func(config *solverConfig) {
+		config.exact = true
+	}
+}
+
+// NewRationalTableau Build the initial rational tableau for the standard form of the linear program
+func NewRationalTableau(lp *LinearProgram) *RationalTableau {
+	objective, constraints := lp.standardForm()
+	return newRationalTableau(newTableau(objective, constraints, lp.Sense))
+}
+
+// newRationalTableau Convert an initial tableau to rational numbers, recalculating the derived rows exactly
+func newRationalTableau(t *Tableau) *RationalTableau {
+	rt := &RationalTableau{
+		NamesRow:       append([]string{}, t.NamesRow...),
+		ObjectiveRow:   ratsFromFloats(t.ObjectiveRow.Values),
+		ConstraintRows: make([][]*big.Rat, len(t.ConstraintRows)),
+		BasisNames:     append([]string{}, t.BasisNames...),
+		BasisColumn:    ratsFromFloats(t.BasisColumn.Values),
+		BColumn:        ratsFromFloats(t.BColumn.Values),
+		ZRow:           ratsFromFloats(t.ZRow.Values),
+		CZRow:          ratsFromFloats(t.CZRow.Values),
+		TableauValue:   new(big.Rat),
+		Sense:          t.Sense,
+		Variables:      append([]LpVariable{}, t.Variables...),
+	}
+	for i, r := range t.ConstraintRows {
+		rt.ConstraintRows[i] = ratsFromFloats(r.Values)
+	}
+	if t.upper != nil {
+		rt.upper = make([]*big.Rat, len(t.upper))
+		for j, u := range t.upper {
+			if !math.IsInf(u, 1) {
+				rt.upper[j] = ratFromFloat(u)
+			}
+		}
+		rt.complemented = append([]bool{}, t.complemented...)
+	}
+	rt.update()
+	return rt
+}
+
+// String Render the tableau as an aligned table of exact fractions
+func (t *RationalTableau) String() string {
+	return t.grid().String()
+}
+
+func (t *RationalTableau) grid() tableauGrid {
+	return newTableauGrid(t.NamesRow, t.BasisNames, len(t.ConstraintRows), func(row, column int) string {
+		switch {
+		case row == gridCostRow:
+			return formatRat(t.ObjectiveRow[column])
+		case row == gridZRow && column == gridValueColumn:
+			return formatRat(t.TableauValue)
+		case row == gridZRow:
+			return formatRat(t.ZRow[column])
+		case row == gridCZRow:
+			return formatRat(t.CZRow[column])
+		case column == gridBasisCostColumn:
+			return formatRat(t.BasisColumn[row])
+		case column == gridValueColumn:
+			return formatRat(t.BColumn[row])
+		}
+		return formatRat(t.ConstraintRows[row][column])
+	})
+}
+
+// Float Convert the tableau to a float64 Tableau
+func (t *RationalTableau) Float() *Tableau {
+	ft := &Tableau{
+		NamesRow:       append([]string{}, t.NamesRow...),
+		ObjectiveRow:   Row{Values: floatsFromRats(t.ObjectiveRow)},
+		ConstraintRows: make([]Row, len(t.ConstraintRows)),
+		BasisNames:     append([]string{}, t.BasisNames...),
+		BasisColumn:    Column{Values: floatsFromRats(t.BasisColumn)},
+		BColumn:        Column{Values: floatsFromRats(t.BColumn)},
+		ZRow:           Row{Values: floatsFromRats(t.ZRow)},
+		CZRow:          Row{Values: floatsFromRats(t.CZRow)},
+		Sense:          t.Sense,
+		Variables:      append([]LpVariable{}, t.Variables...),
+	}
+	ft.TableauValue, _ = t.TableauValue.Float64()
+	for i, r := range t.ConstraintRows {
+		ft.ConstraintRows[i] = Row{Values: floatsFromRats(r)}
+	}
+	if t.upper != nil {
+		ft.upper = make([]float64, len(t.upper))
+		for j, u := range t.upper {
+			ft.upper[j] = math.Inf(1)
+			if u != nil {
+				ft.upper[j], _ = u.Float64()
+			}
+		}
+		ft.complemented = append([]bool{}, t.complemented...)
+	}
+	return ft
+}
+
+// phaseOne Get a copy of the tableau that maximises minus the sum of the artificial variables, see Tableau.phaseOne.
+// Entries are replaced rather than changed in place, so the copy can share them.
+func (t *RationalTableau) phaseOne() simplexTableau {
+	p := *t
+	p.NamesRow = append([]string{}, t.NamesRow...)
+	p.ObjectiveRow = make([]*big.Rat, len(t.ObjectiveRow))
+	p.ConstraintRows = make([][]*big.Rat, len(t.ConstraintRows))
+	for i, row := range t.ConstraintRows {
+		p.ConstraintRows[i] = append([]*big.Rat{}, row...)
+	}
+	p.BasisNames = append([]string{}, t.BasisNames...)
+	p.BasisColumn = make([]*big.Rat, len(t.BasisColumn))
+	p.BColumn = append([]*big.Rat{}, t.BColumn...)
+	p.ZRow = make([]*big.Rat, len(t.ZRow))
+	p.CZRow = make([]*big.Rat, len(t.CZRow))
+	if t.upper != nil {
+		p.complemented = append([]bool{}, t.complemented...)
+	}
+	for j, name := range p.NamesRow {
+		p.ObjectiveRow[j] = new(big.Rat)
+		if p.isArtificial(name) {
+			p.ObjectiveRow[j].SetInt64(-1)
+		}
+	}
+	for i, name := range p.BasisNames {
+		p.BasisColumn[i] = p.ObjectiveRow[p.columnIndex(name)]
+	}
+	p.update()
+	return &p
+}
+
+func (t *RationalTableau) snapshot() *Tableau {
+	return t.Float()
+}
+
+// Pivot Perform a single iteration of the simplex method, returning false if no pivot was made because the tableau
+// is already optimal or the pivot column is unbounded. When the slack of a range constraint reaches the other end of
+// its range first, it is moved there instead of pivoting.
+func (t *RationalTableau) Pivot() bool {
+	if t.IsOptimal() {
+		return false
+	}
+	pivotColumnIndex := t.pivotColumn()
+	if t.boundStep(pivotColumnIndex) {
+		return true
+	}
+	pivotRowIndex, ok := t.pivotRow(pivotColumnIndex)
+	if !ok {
+		return false
+	}
+	t.pivotOn(pivotRowIndex, pivotColumnIndex)
+	return true
+}
+
+// IsOptimal Check that no entry of the CZRow is positive
+func (t *RationalTableau) IsOptimal() bool {
+	for _, v := range t.CZRow {
+		if v.Sign() > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// IsFeasible Check that no artificial variable remains in the basis at a positive value
+func (t *RationalTableau) IsFeasible() bool {
+	for i, v := range t.BasisNames {
+		if t.isArtificial(v) && t.BColumn[i].Sign() > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// GetSolution Get the value of each basic variable
+func (t *RationalTableau) GetSolution() map[string]*big.Rat {
+	solution := make(map[string]*big.Rat)
+	for i, v := range t.BasisNames {
+		solution[v] = new(big.Rat).Set(t.BColumn[i])
+	}
+	for j, complemented := range t.complemented {
+		if complemented {
+			value := new(big.Rat).Set(t.upper[j])
+			if v, ok := solution[t.NamesRow[j]]; ok {
+				value.Sub(value, v)
+			}
+			solution[t.NamesRow[j]] = value
+		}
+	}
+	return solution
+}
+
+// pivotColumn Find the column with the largest value in the CZRow
+func (t *RationalTableau) pivotColumn() int {
+	pivotColumnIndex := 0
+	for i, v := range t.CZRow {
+		if v.Cmp(t.CZRow[pivotColumnIndex]) > 0 {
+			pivotColumnIndex = i
+		}
+	}
+	return pivotColumnIndex
+}
+
+// pivotRow Find the row with the smallest ratio in the given column, returning false if no row limits the column
+func (t *RationalTableau) pivotRow(pivotColumnIndex int) (int, bool) {
+	pivotRowIndex := -1
+	var optimumColumnRatio *big.Rat
+	for i, v := range t.BColumn {
+		entry := t.ConstraintRows[i][pivotColumnIndex]
+		if entry.Sign() <= 0 {
+			continue
+		}
+		ratio := new(big.Rat).Quo(v, entry)
+		if optimumColumnRatio == nil || ratio.Cmp(optimumColumnRatio) < 0 {
+			optimumColumnRatio = ratio
+			pivotRowIndex = i
+		}
+	}
+	return pivotRowIndex, pivotRowIndex >= 0
+}
+
+// blandColumn Find the first column with a positive value in the CZRow, see Tableau.blandColumn
+func (t *RationalTableau) blandColumn() int {
+	for i, v := range t.CZRow {
+		if v.Sign() > 0 {
+			return i
+		}
+	}
+	return t.pivotColumn()
+}
+
+// blandRow Find the row with the smallest ratio in the given column, breaking ties by the first basic variable in
+// column order, see Tableau.blandRow
+func (t *RationalTableau) blandRow(pivotColumnIndex int) (int, bool) {
+	pivotRowIndex, ok := t.pivotRow(pivotColumnIndex)
+	if !ok {
+		return pivotRowIndex, ok
+	}
+	ratio := func(i int) *big.Rat {
+		return new(big.Rat).Quo(t.BColumn[i], t.ConstraintRows[i][pivotColumnIndex])
+	}
+	optimumColumnRatio := ratio(pivotRowIndex)
+	for i := range t.BColumn {
+		if t.ConstraintRows[i][pivotColumnIndex].Sign() > 0 && ratio(i).Cmp(optimumColumnRatio) == 0 &&
+			t.columnIndex(t.BasisNames[i]) < t.columnIndex(t.BasisNames[pivotRowIndex]) {
+			pivotRowIndex = i
+		}
+	}
+	return pivotRowIndex, true
+}
+
+// value Get the value of the objective in the tableau, rounded to a float64
+func (t *RationalTableau) value() float64 {
+	value, _ := t.TableauValue.Float64()
+	return value
+}
+
+// boundStep Keep the bounded variables within their bounds as the variable in the given column enters, see
+// Tableau.boundStep
+func (t *RationalTableau) boundStep(pivotColumnIndex int) bool {
+	if t.upper == nil {
+		return false
+	}
+	var step *big.Rat
+	if pivotRowIndex, ok := t.pivotRow(pivotColumnIndex); ok {
+		step = new(big.Rat).Quo(t.BColumn[pivotRowIndex], t.ConstraintRows[pivotRowIndex][pivotColumnIndex])
+	}
+	row := -1
+	var rise *big.Rat
+	for i, name := range t.BasisNames {
+		a := t.ConstraintRows[i][pivotColumnIndex]
+		upper := t.upper[t.columnIndex(name)]
+		if a.Sign() >= 0 || upper == nil {
+			continue
+		}
+		ratio := new(big.Rat).Sub(upper, t.BColumn[i])
+		ratio.Quo(ratio, new(big.Rat).Neg(a))
+		if rise == nil || ratio.Cmp(rise) < 0 {
+			row, rise = i, ratio
+		}
+	}
+
+	// nil stands for an unlimited step
+	less := func(a, b *big.Rat) bool {
+		return a != nil && (b == nil || a.Cmp(b) < 0)
+	}
+	if upper := t.upper[pivotColumnIndex]; upper != nil && !less(step, upper) && !less(rise, upper) {
+		t.complement(pivotColumnIndex)
+		return true
+	}
+	if less(rise, step) {
+		t.complement(t.columnIndex(t.BasisNames[row]))
+	}
+	return false
+}
+
+// artificialPivot Find a pivot that takes an artificial variable left in the basis at zero out of it, see
+// Tableau.artificialPivot
+func (t *RationalTableau) artificialPivot() (int, int, bool) {
+	for i, name := range t.BasisNames {
+		if !t.isArtificial(name) || t.BColumn[i].Sign() != 0 || t.BasisColumn[i].Sign() == 0 {
+			continue
+		}
+		for j, a := range t.ConstraintRows[i] {
+			if a.Sign() != 0 && !t.isArtificial(t.NamesRow[j]) {
+				return i, j, true
+			}
+		}
+		t.ObjectiveRow[t.columnIndex(name)] = new(big.Rat)
+		t.BasisColumn[i] = new(big.Rat)
+		t.update()
+	}
+	return -1, -1, false
+}
+
+// complement Replace the bounded variable in the given column by its distance below its upper bound, or back again
+func (t *RationalTableau) complement(column int) {
+	upper := t.upper[column]
+	product := new(big.Rat)
+	for i, row := range t.ConstraintRows {
+		t.BColumn[i] = new(big.Rat).Sub(t.BColumn[i], product.Mul(row[column], upper))
+		row[column] = new(big.Rat).Neg(row[column])
+	}
+	for i, name := range t.BasisNames {
+		if name != t.NamesRow[column] {
+			continue
+		}
+		for j := range t.ConstraintRows[i] {
+			t.ConstraintRows[i][j] = new(big.Rat).Neg(t.ConstraintRows[i][j])
+		}
+		t.BColumn[i] = new(big.Rat).Neg(t.BColumn[i])
+	}
+	t.complemented[column] = !t.complemented[column]
+	t.update()
+}
+
+// columnIndex Get the column of the named variable, or -1 if there is no such column
+func (t *RationalTableau) columnIndex(name string) int {
+	for i, v := range t.NamesRow {
+		if v == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// pivotOn Bring the variable in the given column into the basis in place of the variable in the given row
+func (t *RationalTableau) pivotOn(pivotRowIndex, pivotColumnIndex int) {
+	oldBasisName := t.BasisNames[pivotRowIndex]
+	t.BasisNames[pivotRowIndex] = t.NamesRow[pivotColumnIndex]
+	t.BasisColumn[pivotRowIndex] = new(big.Rat).Set(t.ObjectiveRow[pivotColumnIndex])
+
+	// If variable being replaced is artificial, remove it from the problem so it cannot re-enter
+	if t.isArtificial(oldBasisName) {
+		for i, v := range t.NamesRow {
+			if v == oldBasisName {
+				t.ObjectiveRow[i] = new(big.Rat)
+				for j := range t.ConstraintRows {
+					t.ConstraintRows[j][i] = new(big.Rat)
+				}
+			}
+		}
+	}
+
+	pivotRow := t.ConstraintRows[pivotRowIndex]
+	pivotRowValue := new(big.Rat).Set(pivotRow[pivotColumnIndex])
+	for i := range pivotRow {
+		pivotRow[i] = new(big.Rat).Quo(pivotRow[i], pivotRowValue)
+	}
+	t.BColumn[pivotRowIndex] = new(big.Rat).Quo(t.BColumn[pivotRowIndex], pivotRowValue)
+
+	product := new(big.Rat)
+	for i := range t.ConstraintRows {
+		if i == pivotRowIndex {
+			continue
+		}
+		multiplier := new(big.Rat).Set(t.ConstraintRows[i][pivotColumnIndex])
+		if multiplier.Sign() == 0 {
+			continue
+		}
+		for j := range t.ConstraintRows[i] {
+			t.ConstraintRows[i][j] = new(big.Rat).Sub(t.ConstraintRows[i][j], product.Mul(multiplier, pivotRow[j]))
+		}
+		t.BColumn[i] = new(big.Rat).Sub(t.BColumn[i], product.Mul(multiplier, t.BColumn[pivotRowIndex]))
+	}
+
+	t.update()
+}
+
+// update Recalculate the Z row, CZ row and tableau value from the current basis
+func (t *RationalTableau) update() {
+	product := new(big.Rat)
+	for i := range t.ZRow {
+		val := new(big.Rat)
+		for j := range t.ConstraintRows {
+			val.Add(val, product.Mul(t.ConstraintRows[j][i], t.BasisColumn[j]))
+		}
+		t.ZRow[i] = val
+		t.CZRow[i] = new(big.Rat).Sub(t.ObjectiveRow[i], val)
+	}
+
+	t.TableauValue = new(big.Rat)
+	for i, v := range t.BColumn {
+		t.TableauValue.Add(t.TableauValue, product.Mul(v, t.BasisColumn[i]))
+	}
+}
+
+func (t *RationalTableau) isArtificial(name string) bool {
+	for _, v := range t.Variables {
+		if v.Name == name {
+			return v.IsArtificial
+		}
+	}
+	return false
+}
+
+// duals Solve B^T y = c_B exactly for the shadow prices of the standard form rows
+func (t *RationalTableau) duals(constraints []_constraint) []*big.Rat {
+	n := len(constraints)
+	basis := make([][]*big.Rat, n)
+	for k := range basis {
+		basis[k] = make([]*big.Rat, n)
+		for i := range basis[k] {
+			basis[k][i] = new(big.Rat)
+		}
+	}
+	for i, c := range constraints {
+		for _, term := range c.Terms {
+			for k, name := range t.BasisNames {
+				if name == term.Variable.Name {
+					basis[k][i].Add(basis[k][i], ratFromFloat(term.Coefficient))
+				}
+			}
+		}
+	}
+
+	y, ok := solveRationalSystem(basis, t.BasisColumn)
+	if !ok {
+		return nil
+	}
+	return y
+}
+
+// solveRationalSystem Solve the square system a * x = b exactly by Gaussian elimination, returning false if the
+// system is singular. Neither argument is modified.
+func solveRationalSystem(a [][]*big.Rat, b []*big.Rat) ([]*big.Rat, bool) {
+	n := len(b)
+	m := make([][]*big.Rat, n)
+	for i := range a {
+		m[i] = make([]*big.Rat, n+1)
+		for j := range a[i] {
+			m[i][j] = new(big.Rat).Set(a[i][j])
+		}
+		m[i][n] = new(big.Rat).Set(b[i])
+	}
+
+	product := new(big.Rat)
+	for col := 0; col < n; col++ {
+		pivot := -1
+		for row := col; row < n; row++ {
+			if m[row][col].Sign() != 0 {
+				pivot = row
+				break
+			}
+		}
+		if pivot < 0 {
+			return nil, false
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+
+		for row := col + 1; row < n; row++ {
+			if m[row][col].Sign() == 0 {
+				continue
+			}
+			factor := new(big.Rat).Quo(m[row][col], m[col][col])
+			for k := col; k <= n; k++ {
+				m[row][k].Sub(m[row][k], product.Mul(factor, m[col][k]))
+			}
+		}
+	}
+
+	x := make([]*big.Rat, n)
+	for row := n - 1; row >= 0; row-- {
+		val := new(big.Rat).Set(m[row][n])
+		for k := row + 1; k < n; k++ {
+			val.Sub(val, product.Mul(m[row][k], x[k]))
+		}
+		x[row] = val.Quo(val, m[row][row])
+	}
+	return x, true
+}
+
+// ratFromFloat Convert a model coefficient to a rational through its shortest decimal form, so that 0.1 becomes 1/10
+// rather than the nearest binary fraction
+func ratFromFloat(value float64) *big.Rat {
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(value, 'g', -1, 64))
+	if !ok {
+		return new(big.Rat).SetFloat64(value)
+	}
+	return r
+}
+
+func ratsFromFloats(values []float64) []*big.Rat {
+	rats := make([]*big.Rat, len(values))
+	for i, v := range values {
+		rats[i] = ratFromFloat(v)
+	}
+	return rats
+}
+
+func floatsFromRats(rats []*big.Rat) []float64 {
+	values := make([]float64, len(rats))
+	for i, r := range rats {
+		values[i], _ = r.Float64()
+	}
+	return values
+}
+
+// formatRat Format a rational exactly, writing big-M multiples in terms of M
+func formatRat(value *big.Rat) string {
+	if f, _ := value.Float64(); f >= bigM/1e6 || f <= -bigM/1e6 {
+		return formatTableauValue(f, true)
+	}
+	return value.RatString()
+}
@@ -0,0 +1,104 @@
+package gulp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// jsonModel The JSON form of a linear program read by ReadJSON
+type jsonModel struct {
+	Sense     string `json:"sense"`
+	Variables []struct {
+		Name string `json:"name"`
+	} `json:"variables"`
+	Objective   []jsonTerm `json:"objective"`
+	Constraints []struct {
+		Name  string     `json:"name"`
+		Terms []jsonTerm `json:"terms"`
+		Type  string     `json:"type"`
+		RHS   float64    `json:"rhs"`
+	} `json:"constraints"`
+}
+
+type jsonTerm struct {
+	Name        string  `json:"name"`
+	Coefficient float64 `json:"coefficient"`
+}
+
+// ReadJSON Read a linear program from JSON. The variables list is optional and fixes the variable order, constraint
+// names are optional and default to c1, c2, ... as with AddConstraint.
+//
+//	{
+//	  "sense": "maximise",
+//	  "objective": [{"name": "Apples", "coefficient": 7}, {"name": "Bananas", "coefficient": 6}],
+//	  "constraints": [
+//	    {"name": "water", "terms": [{"name": "Apples", "coefficient": 2}, {"name": "Bananas", "coefficient": 4}], "type": "<=", "rhs": 16}
+//	  ]
+//	}
+func ReadJSON(r io.Reader) (lp *LinearProgram, err error) {
+	defer recoverModelError(&err)
+
+	var m jsonModel
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&m); err != nil {
+		return nil, fmt.Errorf("json: %v", err)
+	}
+
+	var sense LpSense
+	switch strings.ToLower(m.Sense) {
+	case "max", "maximise", "maximize":
+		sense = LpMaximise
+	case "min", "minimise", "minimize":
+		sense = LpMinimise
+	default:
+		return nil, fmt.Errorf("json: unknown sense %q", m.Sense)
+	}
+	if len(m.Objective) == 0 {
+		return nil, fmt.Errorf("json: objective function is empty")
+	}
+
+	model := NewLinearProgram()
+	for _, v := range m.Variables {
+		model.AddVariable(NewVariable(v.Name))
+	}
+	model.AddObjective(sense, NewExpression(jsonTerms(m.Objective)))
+
+	for _, c := range m.Constraints {
+		constraintType, ok := parseRelation(c.Type)
+		if !ok {
+			switch strings.ToUpper(c.Type) {
+			case "LE":
+				constraintType, ok = LpConstraintLE, true
+			case "GE":
+				constraintType, ok = LpConstraintGE, true
+			case "EQ":
+				constraintType, ok = LpConstraintEQ, true
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("json: constraint %q: unknown type %q", c.Name, c.Type)
+		}
+		if len(c.Terms) == 0 {
+			return nil, fmt.Errorf("json: constraint %q has no terms", c.Name)
+		}
+
+		name := c.Name
+		if name == "" {
+			name = model.nextConstraintName()
+		}
+		model.AddNamedConstraint(name, NewExpression(jsonTerms(c.Terms)), constraintType, c.RHS)
+	}
+
+	return &model, nil
+}
+
+func jsonTerms(terms []jsonTerm) []LpTerm {
+	out := make([]LpTerm, len(terms))
+	for i, t := range terms {
+		out[i] = NewTerm(t.Coefficient, NewVariable(t.Name))
+	}
+	return out
+}
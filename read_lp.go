@@ -0,0 +1,395 @@
+package gulp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ReadLP Read a linear program in the CPLEX LP file format. The objective, constraint, general and binary sections
+// are supported, with the variables listed under General or Binary given that category; bounds are rejected.
+//
+//	\ A comment
+//	Maximize
+//	 profit: 7 Apples + 6 Bananas
+//	Subject To
+//	 water: 2 Apples + 4 Bananas <= 16
+//	 land: 3 Apples + 2 Bananas <= 12
+//	General
+//	 Apples
+//	End
+func ReadLP(r io.Reader) (lp *LinearProgram, err error) {
+	defer recoverModelError(&err)
+
+	sections, err := splitLPSections(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var sense LpSense
+	var objectiveText string
+	var constraintsText string
+	var categorySections []lpSection
+	for _, section := range sections {
+		switch section.keyword {
+		case "max":
+			sense, objectiveText = LpMaximise, section.text
+		case "min":
+			sense, objectiveText = LpMinimise, section.text
+		case "st":
+			constraintsText += " " + section.text
+		case "general", "binary":
+			categorySections = append(categorySections, section)
+		case "bounds":
+			if strings.TrimSpace(section.text) != "" {
+				return nil, fmt.Errorf("lp: %v section is not supported", section.keyword)
+			}
+		}
+	}
+	if sense == 0 {
+		return nil, fmt.Errorf("lp: missing objective section")
+	}
+
+	tokens, err := tokenizeLP(objectiveText)
+	if err != nil {
+		return nil, err
+	}
+	p := &lpParser{tokens: tokens}
+	p.parseName()
+	objective, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("lp: unexpected %q in objective", p.peek().text)
+	}
+	if len(objective.Terms) == 0 {
+		return nil, fmt.Errorf("lp: objective function is empty")
+	}
+
+	model := NewLinearProgram()
+	model.AddObjective(sense, objective)
+
+	tokens, err = tokenizeLP(constraintsText)
+	if err != nil {
+		return nil, err
+	}
+	p = &lpParser{tokens: tokens}
+	for !p.done() {
+		name, expression, constraintType, rightHandSide, err := p.parseConstraint()
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			name = model.nextConstraintName()
+		}
+		model.AddNamedConstraint(name, expression, constraintType, rightHandSide)
+	}
+
+	// The general and binary sections list variable names, separated by white space
+	for _, section := range categorySections {
+		category := LpInteger
+		if section.keyword == "binary" {
+			category = LpBinary
+		}
+		tokens, err = tokenizeLP(section.text)
+		if err != nil {
+			return nil, err
+		}
+		for _, token := range tokens {
+			if token.kind != lpName {
+				return nil, fmt.Errorf("lp: expected a variable in %v section, got %q", section.keyword, token.text)
+			}
+			model.SetCategory(NewVariable(token.text), category)
+		}
+	}
+
+	return &model, nil
+}
+
+// ParseExpression Parse a sum of terms written as in the LP format, such as "7 Apples + 6 Bananas"
+func ParseExpression(text string) (LpExpression, error) {
+	tokens, err := tokenizeLP(text)
+	if err != nil {
+		return LpExpression{}, err
+	}
+	p := &lpParser{tokens: tokens}
+	expression, err := p.parseExpression()
+	if err != nil {
+		return LpExpression{}, err
+	}
+	if !p.done() {
+		return LpExpression{}, fmt.Errorf("lp: unexpected %q in expression", p.peek().text)
+	}
+	return expression, nil
+}
+
+// ParseConstraint Parse a constraint written as in the LP format, such as "water: 2 Apples + 4 Bananas <= 16". The name
+// is optional and left empty when it is not given.
+func ParseConstraint(text string) (*LpConstraint, error) {
+	tokens, err := tokenizeLP(text)
+	if err != nil {
+		return nil, err
+	}
+	p := &lpParser{tokens: tokens}
+	name, expression, constraintType, rightHandSide, err := p.parseConstraint()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("lp: unexpected %q after constraint", p.peek().text)
+	}
+	return &LpConstraint{Name: name, ConstraintType: constraintType, Terms: expression.Terms, RightHandSide: rightHandSide}, nil
+}
+
+// recoverModelError Turn a panic raised while building a model from a file into an error
+func recoverModelError(err *error) {
+	if r := recover(); r != nil {
+		message, ok := r.(string)
+		if !ok {
+			panic(r)
+		}
+		*err = fmt.Errorf("invalid model: %v", message)
+	}
+}
+
+type lpSection struct {
+	keyword string
+	text    string
+}
+
+// lpSectionKeywords Map the section headers of the LP format onto their canonical keyword
+var lpSectionKeywords = map[string]string{
+	"maximize": "max", "maximise": "max", "maximum": "max", "max": "max",
+	"minimize": "min", "minimise": "min", "minimum": "min", "min": "min",
+	"subject to": "st", "such that": "st", "st": "st", "s.t.": "st", "st.": "st",
+	"bounds": "bounds", "bound": "bounds",
+	"general": "general", "generals": "general", "gen": "general", "integer": "general", "integers": "general",
+	"binary": "binary", "binaries": "binary", "bin": "binary",
+	"end": "end",
+}
+
+// splitLPSections Strip comments and split the file into its sections
+func splitLPSections(r io.Reader) ([]lpSection, error) {
+	var sections []lpSection
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "\\"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		// A section header may be followed by content on the same line
+		lower := strings.ToLower(line)
+		matched := ""
+		for header := range lpSectionKeywords {
+			if (lower == header || strings.HasPrefix(lower, header+" ")) && len(header) > len(matched) {
+				matched = header
+			}
+		}
+		if matched != "" {
+			keyword := lpSectionKeywords[matched]
+			if keyword == "end" {
+				break
+			}
+			sections = append(sections, lpSection{keyword: keyword, text: line[len(matched):]})
+			continue
+		}
+
+		if len(sections) == 0 {
+			return nil, fmt.Errorf("lp: expected a section header, got %q", line)
+		}
+		sections[len(sections)-1].text += " " + line
+	}
+	return sections, scanner.Err()
+}
+
+type lpTokenKind int
+
+const (
+	lpNumber = lpTokenKind(iota)
+	lpName
+	lpSign
+	lpRelation
+	lpColon
+)
+
+type lpToken struct {
+	kind  lpTokenKind
+	text  string
+	value float64
+}
+
+// tokenizeLP Split LP format text into numbers, names, signs, relations and colons
+func tokenizeLP(text string) ([]lpToken, error) {
+	var tokens []lpToken
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '+' || c == '-':
+			tokens = append(tokens, lpToken{kind: lpSign, text: string(c)})
+			i++
+		case c == ':':
+			tokens = append(tokens, lpToken{kind: lpColon, text: ":"})
+			i++
+		case c == '<' || c == '>' || c == '=':
+			j := i + 1
+			for j < len(runes) && (runes[j] == '<' || runes[j] == '>' || runes[j] == '=') {
+				j++
+			}
+			tokens = append(tokens, lpToken{kind: lpRelation, text: string(runes[i:j])})
+			i = j
+		case unicode.IsDigit(c) || c == '.':
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			// An exponent must be followed by digits, otherwise the e starts a name as in 3e1x
+			if j < len(runes) && (runes[j] == 'e' || runes[j] == 'E') {
+				k := j + 1
+				if k < len(runes) && (runes[k] == '+' || runes[k] == '-') {
+					k++
+				}
+				if k < len(runes) && unicode.IsDigit(runes[k]) {
+					for k < len(runes) && unicode.IsDigit(runes[k]) {
+						k++
+					}
+					j = k
+				}
+			}
+			value, err := strconv.ParseFloat(string(runes[i:j]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("lp: invalid number %q", string(runes[i:j]))
+			}
+			tokens = append(tokens, lpToken{kind: lpNumber, text: string(runes[i:j]), value: value})
+			i = j
+		case isLPNameRune(c):
+			j := i
+			for j < len(runes) && (isLPNameRune(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, lpToken{kind: lpName, text: string(runes[i:j])})
+			i = j
+		default:
+			return nil, fmt.Errorf("lp: unexpected character %q", c)
+		}
+	}
+	return tokens, nil
+}
+
+// isLPNameRune Check whether the rune may start a name in the LP format
+func isLPNameRune(c rune) bool {
+	return unicode.IsLetter(c) || strings.ContainsRune("_!\"#$%&()/,;?@'`{}|~[]", c)
+}
+
+type lpParser struct {
+	tokens   []lpToken
+	position int
+}
+
+func (p *lpParser) done() bool {
+	return p.position >= len(p.tokens)
+}
+
+func (p *lpParser) peek() lpToken {
+	if p.done() {
+		return lpToken{kind: -1, text: "end of input"}
+	}
+	return p.tokens[p.position]
+}
+
+// parseName Consume a "name:" label if there is one
+func (p *lpParser) parseName() string {
+	if p.position+1 < len(p.tokens) && p.tokens[p.position].kind == lpName && p.tokens[p.position+1].kind == lpColon {
+		name := p.tokens[p.position].text
+		p.position += 2
+		return name
+	}
+	return ""
+}
+
+// parseExpression Parse a sum of terms such as "3 x - y + 2.5 z", stopping at a relation or the end of the input
+func (p *lpParser) parseExpression() (LpExpression, error) {
+	var terms []LpTerm
+	for !p.done() && p.peek().kind != lpRelation {
+		sign := 1.0
+		for p.peek().kind == lpSign {
+			if p.peek().text == "-" {
+				sign = -sign
+			}
+			p.position++
+		}
+
+		coefficient := 1.0
+		if p.peek().kind == lpNumber {
+			coefficient = p.peek().value
+			p.position++
+		}
+
+		if p.peek().kind != lpName {
+			if !p.done() && p.peek().kind != lpRelation && p.peek().kind != lpSign {
+				return LpExpression{}, fmt.Errorf("lp: expected a variable, got %q", p.peek().text)
+			}
+			return LpExpression{}, fmt.Errorf("lp: constant terms are not supported")
+		}
+		terms = append(terms, NewTerm(sign*coefficient, NewVariable(p.peek().text)))
+		p.position++
+	}
+	return NewExpression(terms), nil
+}
+
+// parseConstraint Parse "name: expression relation rhs", the name is optional
+func (p *lpParser) parseConstraint() (string, LpExpression, LpConstraintType, float64, error) {
+	name := p.parseName()
+	expression, err := p.parseExpression()
+	if err != nil {
+		return "", LpExpression{}, 0, 0, err
+	}
+	if len(expression.Terms) == 0 {
+		return "", LpExpression{}, 0, 0, fmt.Errorf("lp: constraint %q has no terms", name)
+	}
+
+	constraintType, ok := parseRelation(p.peek().text)
+	if p.peek().kind != lpRelation || !ok {
+		return "", LpExpression{}, 0, 0, fmt.Errorf("lp: expected a relation, got %q", p.peek().text)
+	}
+	p.position++
+
+	sign := 1.0
+	for p.peek().kind == lpSign {
+		if p.peek().text == "-" {
+			sign = -sign
+		}
+		p.position++
+	}
+	if p.peek().kind != lpNumber {
+		return "", LpExpression{}, 0, 0, fmt.Errorf("lp: expected a right hand side, got %q", p.peek().text)
+	}
+	rightHandSide := sign * p.peek().value
+	p.position++
+
+	return name, expression, constraintType, rightHandSide, nil
+}
+
+// parseRelation Parse a relation such as <=, =<, <, >=, => or =
+func parseRelation(text string) (LpConstraintType, bool) {
+	switch text {
+	case "<=", "=<", "<":
+		return LpConstraintLE, true
+	case ">=", "=>", ">":
+		return LpConstraintGE, true
+	case "=", "==":
+		return LpConstraintEQ, true
+	}
+	return 0, false
+}
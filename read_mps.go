@@ -0,0 +1,207 @@
+package gulp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ReadMPS Read a linear program in the free MPS format. The NAME, OBJSENSE, ROWS, COLUMNS, RHS and RANGES sections are
+// supported, with ranged rows read as range constraints; bounds and integer markers are rejected. The objective is
+// minimised unless OBJSENSE says otherwise.
+//
+//	NAME          apples
+//	OBJSENSE
+//	    MAX
+//	ROWS
+//	 N  profit
+//	 L  water
+//	 L  land
+//	COLUMNS
+//	    Apples    profit  7   water  2
+//	    Apples    land    3
+//	    Bananas   profit  6   water  4
+//	    Bananas   land    2
+//	RHS
+//	    RHS       water   16  land   12
+//	ENDATA
+func ReadMPS(r io.Reader) (lp *LinearProgram, err error) {
+	defer recoverModelError(&err)
+
+	sense := LpMinimise
+	objectiveName := ""
+	var rowNames []string
+	rowTypes := make(map[string]LpConstraintType)
+	rowTerms := make(map[string][]LpTerm)
+	rightHandSides := make(map[string]float64)
+	ranges := make(map[string]float64)
+	var columns []string
+	columnSeen := make(map[string]bool)
+
+	section := ""
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if strings.HasPrefix(text, "*") || strings.TrimSpace(text) == "" {
+			continue
+		}
+		fields := strings.Fields(text)
+
+		// Section headers start in the first column, data lines are indented
+		if text[0] != ' ' && text[0] != '\t' {
+			section = strings.ToUpper(fields[0])
+			switch section {
+			case "NAME", "ROWS", "COLUMNS", "RHS", "RANGES":
+			case "OBJSENSE":
+				if len(fields) > 1 {
+					if sense, err = parseMPSSense(fields[1]); err != nil {
+						return nil, fmt.Errorf("mps: line %d: %v", line, err)
+					}
+				}
+			case "ENDATA":
+			case "BOUNDS":
+				return nil, fmt.Errorf("mps: line %d: %v section is not supported", line, section)
+			default:
+				return nil, fmt.Errorf("mps: line %d: unknown section %q", line, fields[0])
+			}
+			if section == "ENDATA" {
+				break
+			}
+			continue
+		}
+
+		switch section {
+		case "OBJSENSE":
+			if sense, err = parseMPSSense(fields[0]); err != nil {
+				return nil, fmt.Errorf("mps: line %d: %v", line, err)
+			}
+		case "ROWS":
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("mps: line %d: expected a row type and name", line)
+			}
+			name := fields[1]
+			if _, ok := rowTypes[name]; ok || name == objectiveName {
+				return nil, fmt.Errorf("mps: line %d: duplicate row %q", line, name)
+			}
+			switch strings.ToUpper(fields[0]) {
+			case "N":
+				if objectiveName != "" {
+					return nil, fmt.Errorf("mps: line %d: only one objective row is supported", line)
+				}
+				objectiveName = name
+			case "L":
+				rowTypes[name] = LpConstraintLE
+				rowNames = append(rowNames, name)
+			case "G":
+				rowTypes[name] = LpConstraintGE
+				rowNames = append(rowNames, name)
+			case "E":
+				rowTypes[name] = LpConstraintEQ
+				rowNames = append(rowNames, name)
+			default:
+				return nil, fmt.Errorf("mps: line %d: unknown row type %q", line, fields[0])
+			}
+		case "COLUMNS":
+			if len(fields) > 1 && strings.Contains(strings.ToUpper(text), "'MARKER'") {
+				return nil, fmt.Errorf("mps: line %d: integer markers are not supported", line)
+			}
+			if len(fields) != 3 && len(fields) != 5 {
+				return nil, fmt.Errorf("mps: line %d: expected a column name and one or two row entries", line)
+			}
+			column := fields[0]
+			if !columnSeen[column] {
+				columnSeen[column] = true
+				columns = append(columns, column)
+			}
+			for i := 1; i < len(fields); i += 2 {
+				value, err := strconv.ParseFloat(fields[i+1], 64)
+				if err != nil {
+					return nil, fmt.Errorf("mps: line %d: invalid number %q", line, fields[i+1])
+				}
+				row := fields[i]
+				if _, ok := rowTypes[row]; !ok && row != objectiveName {
+					return nil, fmt.Errorf("mps: line %d: unknown row %q", line, row)
+				}
+				rowTerms[row] = append(rowTerms[row], NewTerm(value, NewVariable(column)))
+			}
+		case "RHS", "RANGES":
+			// The vector name is optional in free MPS
+			if len(fields)%2 == 1 {
+				fields = fields[1:]
+			}
+			for i := 0; i < len(fields); i += 2 {
+				value, err := strconv.ParseFloat(fields[i+1], 64)
+				if err != nil {
+					return nil, fmt.Errorf("mps: line %d: invalid number %q", line, fields[i+1])
+				}
+				row := fields[i]
+				if row == objectiveName && section == "RHS" {
+					return nil, fmt.Errorf("mps: line %d: objective constants are not supported", line)
+				}
+				if _, ok := rowTypes[row]; !ok {
+					return nil, fmt.Errorf("mps: line %d: unknown row %q", line, row)
+				}
+				if section == "RANGES" {
+					ranges[row] = value
+				} else {
+					rightHandSides[row] = value
+				}
+			}
+		default:
+			return nil, fmt.Errorf("mps: line %d: data outside of a section", line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(rowTerms[objectiveName]) == 0 {
+		return nil, fmt.Errorf("mps: objective function is empty")
+	}
+
+	model := NewLinearProgram()
+	for _, column := range columns {
+		model.AddVariable(NewVariable(column))
+	}
+	model.AddObjective(sense, NewExpression(rowTerms[objectiveName]))
+	for _, name := range rowNames {
+		if len(rowTerms[name]) == 0 {
+			return nil, fmt.Errorf("mps: row %q has no entries", name)
+		}
+		r, ok := ranges[name]
+		if !ok {
+			model.AddNamedConstraint(name, NewExpression(rowTerms[name]), rowTypes[name], rightHandSides[name])
+			continue
+		}
+
+		// A range of R gives [rhs - |R|, rhs] on L rows, [rhs, rhs + |R|] on G rows, and extends E rows by R
+		lower, upper := rightHandSides[name], rightHandSides[name]
+		switch {
+		case rowTypes[name] == LpConstraintLE:
+			lower -= math.Abs(r)
+		case rowTypes[name] == LpConstraintGE:
+			upper += math.Abs(r)
+		case r < 0:
+			lower += r
+		default:
+			upper += r
+		}
+		model.AddNamedRangeConstraint(name, NewExpression(rowTerms[name]), lower, upper)
+	}
+
+	return &model, nil
+}
+
+// parseMPSSense Parse the value of an OBJSENSE section
+func parseMPSSense(text string) (LpSense, error) {
+	switch strings.ToUpper(text) {
+	case "MAX", "MAXIMIZE", "MAXIMISE":
+		return LpMaximise, nil
+	case "MIN", "MINIMIZE", "MINIMISE":
+		return LpMinimise, nil
+	}
+	return 0, fmt.Errorf("unknown objective sense %q", text)
+}
@@ -0,0 +1,120 @@
+package gulp
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// ReportFormat The layout used when writing a Result
+type ReportFormat int
+
+const (
+	ReportText     = ReportFormat(0)
+	ReportCSV      = ReportFormat(1)
+	ReportMarkdown = ReportFormat(2)
+)
+
+// WriteReport Write the result in the given format. Variables and constraints are written in model order, so the
+// output of repeated solves can be compared line by line.
+func (r *Result) WriteReport(w io.Writer, format ReportFormat) error {
+	switch format {
+	case ReportText:
+		return r.WriteText(w)
+	case ReportCSV:
+		return r.WriteCSV(w)
+	case ReportMarkdown:
+		return r.WriteMarkdown(w)
+	}
+	return fmt.Errorf("unknown report format %d", format)
+}
+
+// WriteText Write the result as aligned plain text tables
+func (r *Result) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Status:\t%v\n", r.status)
+	fmt.Fprintf(tw, "Objective:\t%v\n", r.formatObjectiveValue())
+	for k, name := range r.secondaryObjectives {
+		fmt.Fprintf(tw, "Objective %v:\t%v\n", name, formatNumber(r.secondaryObjectiveValues[k]))
+	}
+	// Flush between sections so each table is aligned on its own
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(tw, "\nVariable\tValue\tReduced Cost\n")
+	for i, v := range r.variables {
+		fmt.Fprintf(tw, "%v\t%v\t%v\n", v.Name, r.formatValue(r.values, r.exactValues, i), r.formatValue(r.reducedCosts, r.exactReducedCosts, i))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(r.constraints) > 0 {
+		fmt.Fprintf(tw, "\nConstraint\tActivity\tSlack\tDual\n")
+		for i, name := range r.constraints {
+			fmt.Fprintf(tw, "%v\t%v\t%v\t%v\n", name, r.formatValue(r.activities, r.exactActivities, i), r.formatValue(r.slacks, r.exactSlacks, i), r.formatValue(r.duals, r.exactDuals, i))
+		}
+	}
+	return tw.Flush()
+}
+
+// WriteCSV Write the result as a single CSV table with one row per status, objective, variable and constraint
+func (r *Result) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	records := [][]string{
+		{"type", "name", "value", "reduced_cost", "activity", "slack", "dual"},
+		{"status", r.status.String(), "", "", "", "", ""},
+		{"objective", "", r.formatObjectiveValue(), "", "", "", ""},
+	}
+	for k, name := range r.secondaryObjectives {
+		records = append(records, []string{"objective", name, formatNumber(r.secondaryObjectiveValues[k]), "", "", "", ""})
+	}
+	for i, v := range r.variables {
+		records = append(records, []string{"variable", v.Name, r.formatValue(r.values, r.exactValues, i), r.formatValue(r.reducedCosts, r.exactReducedCosts, i), "", "", ""})
+	}
+	for i, name := range r.constraints {
+		records = append(records, []string{"constraint", name, "", "", r.formatValue(r.activities, r.exactActivities, i), r.formatValue(r.slacks, r.exactSlacks, i), r.formatValue(r.duals, r.exactDuals, i)})
+	}
+	return cw.WriteAll(records)
+}
+
+// WriteMarkdown Write the result as Markdown tables
+func (r *Result) WriteMarkdown(w io.Writer) error {
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("**Status:** %v\n\n", r.status))
+	sb.WriteString(fmt.Sprintf("**Objective:** %v\n\n", r.formatObjectiveValue()))
+	for k, name := range r.secondaryObjectives {
+		sb.WriteString(fmt.Sprintf("**Objective %v:** %v\n\n", escapeMarkdown(name), formatNumber(r.secondaryObjectiveValues[k])))
+	}
+
+	sb.WriteString("| Variable | Value | Reduced Cost |\n")
+	sb.WriteString("| --- | ---: | ---: |\n")
+	for i, v := range r.variables {
+		sb.WriteString(fmt.Sprintf("| %v | %v | %v |\n", escapeMarkdown(v.Name), r.formatValue(r.values, r.exactValues, i), r.formatValue(r.reducedCosts, r.exactReducedCosts, i)))
+	}
+
+	if len(r.constraints) > 0 {
+		sb.WriteString("\n| Constraint | Activity | Slack | Dual |\n")
+		sb.WriteString("| --- | ---: | ---: | ---: |\n")
+		for i, name := range r.constraints {
+			sb.WriteString(fmt.Sprintf("| %v | %v | %v | %v |\n", escapeMarkdown(name), r.formatValue(r.activities, r.exactActivities, i), r.formatValue(r.slacks, r.exactSlacks, i), r.formatValue(r.duals, r.exactDuals, i)))
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// PrintSolution Print the result to stdout as plain text
+func (r *Result) PrintSolution() {
+	_ = r.WriteText(os.Stdout)
+}
+
+// escapeMarkdown Escape the characters that would break a Markdown table cell
+func escapeMarkdown(s string) string {
+	return strings.NewReplacer("|", "\\|", "*", "\\*", "_", "\\_").Replace(s)
+}
@@ -0,0 +1,358 @@
+package gulp
+
+import (
+	"math"
+	"math/big"
+	"time"
+)
+
+// Result The outcome of solving a linear program. A Result is a snapshot, it does not change if the linear program it
+// came from is edited or solved again.
+type Result struct {
+	status         LpStatus
+	objectiveValue float64
+	iterations     int
+	solveTime      time.Duration
+
+	// Variables, in model order
+	variables     []LpVariable
+	variableIndex map[string]int
+	values        []float64
+	reducedCosts  []float64
+
+	// Constraints, in model order
+	constraints     []string
+	constraintIndex map[string]int
+	activities      []float64
+	slacks          []float64
+	duals           []float64
+	violations      []float64 // Zero for constraints that are not elastic
+
+	// Secondary objectives, in priority order
+	secondaryObjectives      []string
+	secondaryObjectiveValues []float64
+
+	// Exact values, only set when solved with WithExactArithmetic
+	exactObjectiveValue *big.Rat
+	exactValues         []*big.Rat
+	exactReducedCosts   []*big.Rat
+	exactActivities     []*big.Rat
+	exactSlacks         []*big.Rat
+	exactDuals          []*big.Rat
+}
+
+// newResult Read the result of a finished solve out of the tableau
+func newResult(lp *LinearProgram, constraints []_constraint, tableau *Tableau, status LpStatus, iterations int, solveTime time.Duration) *Result {
+	sense := float64(lp.Sense)
+	r := &Result{
+		status:          status,
+		iterations:      iterations,
+		solveTime:       solveTime,
+		variables:       lp.Variables(),
+		variableIndex:   make(map[string]int, len(lp.variables)),
+		values:          make([]float64, len(lp.variables)),
+		reducedCosts:    make([]float64, len(lp.variables)),
+		constraints:     make([]string, len(lp.Constraints)),
+		constraintIndex: make(map[string]int, len(lp.Constraints)),
+		activities:      make([]float64, len(lp.Constraints)),
+		slacks:          make([]float64, len(lp.Constraints)),
+		duals:           make([]float64, len(lp.Constraints)),
+		violations:      make([]float64, len(lp.Constraints)),
+	}
+
+	switch status {
+	case LpStatusInfeasible, LpStatusNotImplemented:
+		r.objectiveValue = math.NaN()
+	case LpStatusUnbounded:
+		r.objectiveValue = math.Inf(1) * sense
+	default:
+		r.objectiveValue = tableau.TableauValue * sense
+	}
+
+	columns := make(map[string]int, len(tableau.NamesRow))
+	for i, name := range tableau.NamesRow {
+		columns[name] = i
+	}
+
+	solution := tableau.GetSolution()
+	for i, v := range r.variables {
+		r.variableIndex[v.Name] = i
+		r.values[i] = solution[v.Name]
+		if status == LpStatusOptimal {
+			r.reducedCosts[i] = tableau.CZRow.Values[columns[v.Name]] * sense
+		}
+	}
+
+	var duals []float64
+	if status == LpStatusOptimal {
+		duals = tableau.duals(constraints)
+	}
+
+	for i, c := range lp.Constraints {
+		r.constraints[i] = c.Name
+		r.constraintIndex[c.Name] = i
+		for _, t := range c.Terms {
+			r.activities[i] += t.Coefficient * solution[t.Variable.Name]
+		}
+		r.slacks[i] = c.RightHandSide - r.activities[i]
+		if c.Elastic {
+			r.violations[i] = violation(r.activities[i], c.ConstraintType, c.RightHandSide)
+		}
+		if duals != nil {
+			r.duals[i] = duals[i] * sense
+			if constraints[i].Negated {
+				r.duals[i] *= -1
+			}
+		}
+	}
+
+	return r
+}
+
+// duals Solve B^T y = c_B for the shadow prices of the standard form rows
+func (t *Tableau) duals(constraints []_constraint) []float64 {
+	n := len(constraints)
+	basis := make([][]float64, n)
+	for k := range basis {
+		basis[k] = make([]float64, n)
+	}
+	for i, c := range constraints {
+		for _, term := range c.Terms {
+			for k, name := range t.BasisNames {
+				if name == term.Variable.Name {
+					basis[k][i] += term.Coefficient
+				}
+			}
+		}
+	}
+
+	y, ok := solveLinearSystem(basis, t.BasisColumn.Values)
+	if !ok {
+		return nil
+	}
+	return y
+}
+
+// setExact Replace the values read from the float64 copy of a rational tableau with their exact values
+func (r *Result) setExact(lp *LinearProgram, constraints []_constraint, tableau *RationalTableau) {
+	sense := big.NewRat(int64(lp.Sense), 1)
+	optimal := r.status == LpStatusOptimal
+
+	if r.status != LpStatusInfeasible && r.status != LpStatusUnbounded {
+		r.exactObjectiveValue = new(big.Rat).Mul(tableau.TableauValue, sense)
+		r.objectiveValue, _ = r.exactObjectiveValue.Float64()
+	}
+
+	columns := make(map[string]int, len(tableau.NamesRow))
+	for i, name := range tableau.NamesRow {
+		columns[name] = i
+	}
+
+	solution := tableau.GetSolution()
+	value := func(name string) *big.Rat {
+		if v, ok := solution[name]; ok {
+			return v
+		}
+		return new(big.Rat)
+	}
+
+	r.exactValues = make([]*big.Rat, len(r.variables))
+	r.exactReducedCosts = make([]*big.Rat, len(r.variables))
+	for i, v := range r.variables {
+		r.exactValues[i] = value(v.Name)
+		r.exactReducedCosts[i] = new(big.Rat)
+		if optimal {
+			r.exactReducedCosts[i].Mul(tableau.CZRow[columns[v.Name]], sense)
+		}
+		r.values[i], _ = r.exactValues[i].Float64()
+		r.reducedCosts[i], _ = r.exactReducedCosts[i].Float64()
+	}
+
+	var duals []*big.Rat
+	if optimal {
+		duals = tableau.duals(constraints)
+	}
+
+	r.exactActivities = make([]*big.Rat, len(r.constraints))
+	r.exactSlacks = make([]*big.Rat, len(r.constraints))
+	r.exactDuals = make([]*big.Rat, len(r.constraints))
+	for i, c := range lp.Constraints {
+		r.exactActivities[i] = new(big.Rat)
+		for _, t := range c.Terms {
+			r.exactActivities[i].Add(r.exactActivities[i], new(big.Rat).Mul(ratFromFloat(t.Coefficient), value(t.Variable.Name)))
+		}
+		r.exactSlacks[i] = new(big.Rat).Sub(ratFromFloat(c.RightHandSide), r.exactActivities[i])
+		r.exactDuals[i] = new(big.Rat)
+		if duals != nil {
+			r.exactDuals[i].Mul(duals[i], sense)
+			if constraints[i].Negated {
+				r.exactDuals[i].Neg(r.exactDuals[i])
+			}
+		}
+		r.activities[i], _ = r.exactActivities[i].Float64()
+		r.slacks[i], _ = r.exactSlacks[i].Float64()
+		r.duals[i], _ = r.exactDuals[i].Float64()
+		if c.Elastic {
+			r.violations[i] = violation(r.activities[i], c.ConstraintType, c.RightHandSide)
+		}
+	}
+}
+
+// Status Get the status of the solve
+func (r *Result) Status() LpStatus {
+	return r.status
+}
+
+// ObjectiveValue Get the value of the objective function
+func (r *Result) ObjectiveValue() float64 {
+	return r.objectiveValue
+}
+
+// SecondaryObjectiveValue Get the value of the named secondary objective, zero if there is no such objective
+func (r *Result) SecondaryObjectiveValue(name string) float64 {
+	for k, o := range r.secondaryObjectives {
+		if o == name {
+			return r.secondaryObjectiveValues[k]
+		}
+	}
+	return 0
+}
+
+// Iterations Get the number of simplex iterations performed, pivots and bound moves of range constraint slacks
+func (r *Result) Iterations() int {
+	return r.iterations
+}
+
+// SolveTime Get the wall-clock time spent solving
+func (r *Result) SolveTime() time.Duration {
+	return r.solveTime
+}
+
+// Variables Get the variables of the solved model in model order
+func (r *Result) Variables() []LpVariable {
+	return append([]LpVariable{}, r.variables...)
+}
+
+// Value Get the value of a variable, zero if the variable is not part of the model
+func (r *Result) Value(variable LpVariable) float64 {
+	if i, ok := r.variableIndex[variable.Name]; ok {
+		return r.values[i]
+	}
+	return 0
+}
+
+// Values Get the value of every variable, keyed by name
+func (r *Result) Values() map[string]float64 {
+	values := make(map[string]float64, len(r.variables))
+	for i, v := range r.variables {
+		values[v.Name] = r.values[i]
+	}
+	return values
+}
+
+// ReducedCost Get the reduced cost of a variable, the rate the objective changes as the variable is forced up
+func (r *Result) ReducedCost(variable LpVariable) float64 {
+	if i, ok := r.variableIndex[variable.Name]; ok {
+		return r.reducedCosts[i]
+	}
+	return 0
+}
+
+// Constraints Get the names of the constraints of the solved model in model order
+func (r *Result) Constraints() []string {
+	return append([]string{}, r.constraints...)
+}
+
+// Activity Get the value of the left hand side of the named constraint
+func (r *Result) Activity(constraint string) float64 {
+	if i, ok := r.constraintIndex[constraint]; ok {
+		return r.activities[i]
+	}
+	return 0
+}
+
+// Slack Get the right hand side minus the activity of the named constraint
+func (r *Result) Slack(constraint string) float64 {
+	if i, ok := r.constraintIndex[constraint]; ok {
+		return r.slacks[i]
+	}
+	return 0
+}
+
+// Dual Get the shadow price of the named constraint, the rate the objective changes with its right hand side
+func (r *Result) Dual(constraint string) float64 {
+	if i, ok := r.constraintIndex[constraint]; ok {
+		return r.duals[i]
+	}
+	return 0
+}
+
+// Violation Get the amount the named constraint was violated by, always zero unless the constraint is elastic
+func (r *Result) Violation(constraint string) float64 {
+	if i, ok := r.constraintIndex[constraint]; ok {
+		return r.violations[i]
+	}
+	return 0
+}
+
+// IsExact Check whether the result was solved with exact rational arithmetic
+func (r *Result) IsExact() bool {
+	return r.exactValues != nil
+}
+
+// RatObjectiveValue Get the exact value of the objective function, nil unless the result is exact and bounded
+func (r *Result) RatObjectiveValue() *big.Rat {
+	if r.exactObjectiveValue == nil {
+		return nil
+	}
+	return new(big.Rat).Set(r.exactObjectiveValue)
+}
+
+// RatValue Get the exact value of a variable, nil unless the result is exact
+func (r *Result) RatValue(variable LpVariable) *big.Rat {
+	if i, ok := r.variableIndex[variable.Name]; ok && r.exactValues != nil {
+		return new(big.Rat).Set(r.exactValues[i])
+	}
+	return nil
+}
+
+// RatDual Get the exact shadow price of the named constraint, nil unless the result is exact
+func (r *Result) RatDual(constraint string) *big.Rat {
+	if i, ok := r.constraintIndex[constraint]; ok && r.exactDuals != nil {
+		return new(big.Rat).Set(r.exactDuals[i])
+	}
+	return nil
+}
+
+// formatValue Format the i-th entry of a column of the result, as an exact fraction when one is available
+func (r *Result) formatValue(values []float64, exact []*big.Rat, i int) string {
+	if exact != nil {
+		return exact[i].RatString()
+	}
+	return formatNumber(values[i])
+}
+
+// formatObjectiveValue Format the objective value, as an exact fraction when one is available
+func (r *Result) formatObjectiveValue() string {
+	if r.exactObjectiveValue != nil {
+		return r.exactObjectiveValue.RatString()
+	}
+	return formatNumber(r.objectiveValue)
+}
+
+// violation Get how far value is on the wrong side of value {type} rightHandSide, zero within the default tolerance
+func violation(value float64, constraintType LpConstraintType, rightHandSide float64) float64 {
+	var v float64
+	switch constraintType {
+	case LpConstraintLE:
+		v = value - rightHandSide
+	case LpConstraintGE:
+		v = rightHandSide - value
+	default:
+		v = math.Abs(value - rightHandSide)
+	}
+	if v <= DefaultTolerance {
+		return 0
+	}
+	return v
+}
@@ -0,0 +1,238 @@
+package gulp
+
+import (
+	"fmt"
+	"math"
+)
+
+// ScalingMethod How the rows and columns of the constraint matrix are scaled, the methods can be combined with |
+type ScalingMethod int
+
+const (
+	// ScaleGeometric Repeatedly divide each row and column by the geometric mean of its largest and smallest entries
+	ScaleGeometric = ScalingMethod(1)
+	// ScaleEquilibration Divide each row and then each column by its largest entry
+	ScaleEquilibration = ScalingMethod(2)
+	// ScaleGeometricEquilibration Geometric scaling followed by equilibration, the method used by WithScaling
+	ScaleGeometricEquilibration = ScaleGeometric | ScaleEquilibration
+)
+
+// geometricScalingPasses The most passes of geometric scaling, it stops early once a pass no longer helps
+const geometricScalingPasses = 8
+
+// CoefficientRange The smallest and largest absolute values of the non-zero entries of a constraint matrix
+type CoefficientRange struct {
+	Min float64
+	Max float64
+}
+
+// Ratio Get the ratio of the largest to the smallest entry, 1 for an empty matrix
+func (c CoefficientRange) Ratio() float64 {
+	if c.Min == 0 {
+		return 1
+	}
+	return c.Max / c.Min
+}
+
+func (c CoefficientRange) String() string {
+	return fmt.Sprintf("[%v, %v], ratio %v", formatNumber(c.Min), formatNumber(c.Max), formatNumber(c.Ratio()))
+}
+
+// Scaled A linear program with its rows and columns scaled, with the factors needed to map its solution back
+type Scaled struct {
+	// Model The scaled linear program. Row i is multiplied by RowScale[i], and each variable stands for the original
+	// variable divided by its ColumnScale.
+	Model *LinearProgram
+
+	RowScale    []float64
+	ColumnScale []float64
+
+	// Before and After The coefficient range of the constraint matrix before and after scaling
+	Before CoefficientRange
+	After  CoefficientRange
+
+	// The original model, copied so that later edits to it do not affect Unscale
+	variables []LpVariable
+	rows      []sparseRow
+}
+
+// WithScaling Scale the constraint matrix with geometric scaling followed by equilibration before building the
+// tableau, see Scale
+func WithScaling() SolverOption {
+	return WithScalingMethod(ScaleGeometricEquilibration)
+}
+
+// WithScalingMethod Scale the constraint matrix with the given method before building the tableau, see Scale. Scaling
+// is skipped when solving WithExactArithmetic, which has no round-off to avoid.
+func WithScalingMethod(method ScalingMethod) SolverOption {
+	return func(config *solverConfig) {
+		config.scaling = method
+	}
+}
+
+// Scale Scale the rows and columns of the linear program so its coefficients are close to one. Mixing very large and
+// very small coefficients leads the simplex method into pivots on round-off error, which scaling avoids. The scale
+// factors are powers of two so that scaling itself introduces no round-off. Solve the scaled Model and pass its Result
+// to Unscale to get the result for the original linear program.
+func (lp *LinearProgram) Scale(method ScalingMethod) *Scaled {
+	s := &Scaled{
+		variables:   lp.Variables(),
+		rows:        lp.sparseRows(),
+		RowScale:    make([]float64, len(lp.Constraints)),
+		ColumnScale: make([]float64, len(lp.variables)),
+	}
+	for i := range s.RowScale {
+		s.RowScale[i] = 1
+	}
+	for j := range s.ColumnScale {
+		s.ColumnScale[j] = 1
+	}
+	s.Before = s.coefficientRange()
+
+	if method&ScaleGeometric != 0 {
+		ratio := s.Before.Ratio()
+		for pass := 0; pass < geometricScalingPasses; pass++ {
+			s.scaleRows(func(min, max float64) float64 { return math.Sqrt(min * max) })
+			s.scaleColumns(func(min, max float64) float64 { return math.Sqrt(min * max) })
+			next := s.coefficientRange().Ratio()
+			if next > 0.9*ratio {
+				break
+			}
+			ratio = next
+		}
+	}
+	if method&ScaleEquilibration != 0 {
+		s.scaleRows(func(min, max float64) float64 { return max })
+		s.scaleColumns(func(min, max float64) float64 { return max })
+	}
+
+	// Round to powers of two, which scale floating point numbers exactly
+	for i, r := range s.RowScale {
+		s.RowScale[i] = math.Exp2(math.Round(math.Log2(r)))
+	}
+	for j, c := range s.ColumnScale {
+		s.ColumnScale[j] = math.Exp2(math.Round(math.Log2(c)))
+	}
+	s.After = s.coefficientRange()
+
+	s.buildModel(lp)
+	return s
+}
+
+// scaledEntry Get the entry of the scaled constraint matrix in row i and column j
+func (s *Scaled) scaledEntry(i, j int) float64 {
+	return math.Abs(s.RowScale[i] * s.rows[i].coefficients[j] * s.ColumnScale[j])
+}
+
+// coefficientRange Get the range of the scaled constraint matrix
+func (s *Scaled) coefficientRange() CoefficientRange {
+	var r CoefficientRange
+	for i, row := range s.rows {
+		for j := range row.coefficients {
+			r.add(s.scaledEntry(i, j))
+		}
+	}
+	return r
+}
+
+// scaleRows Divide each row by the size given for the range of its entries
+func (s *Scaled) scaleRows(size func(min, max float64) float64) {
+	for i, row := range s.rows {
+		min, max := math.Inf(1), 0.0
+		for j := range row.coefficients {
+			v := s.scaledEntry(i, j)
+			min, max = math.Min(min, v), math.Max(max, v)
+		}
+		if max > 0 {
+			s.RowScale[i] /= size(min, max)
+		}
+	}
+}
+
+// scaleColumns Divide each column by the size given for the range of its entries
+func (s *Scaled) scaleColumns(size func(min, max float64) float64) {
+	mins := make([]float64, len(s.ColumnScale))
+	maxes := make([]float64, len(s.ColumnScale))
+	for j := range mins {
+		mins[j] = math.Inf(1)
+	}
+	for i, row := range s.rows {
+		for j := range row.coefficients {
+			v := s.scaledEntry(i, j)
+			mins[j], maxes[j] = math.Min(mins[j], v), math.Max(maxes[j], v)
+		}
+	}
+	for j := range s.ColumnScale {
+		if maxes[j] > 0 {
+			s.ColumnScale[j] /= size(mins[j], maxes[j])
+		}
+	}
+}
+
+// buildModel Build the scaled linear program, keeping the variable and constraint names and order
+func (s *Scaled) buildModel(lp *LinearProgram) {
+	model := NewLinearProgram()
+	model.AddVariable(s.variables...)
+
+	objective := make([]LpTerm, 0, len(s.variables))
+	for _, t := range lp.userObjective() {
+		j := lp.variableIndex[t.Variable.Name]
+		objective = append(objective, NewTerm(t.Coefficient*s.ColumnScale[j], t.Variable))
+	}
+	model.AddObjective(lp.Sense, NewExpression(objective))
+
+	for i, row := range s.rows {
+		terms := make([]LpTerm, 0, len(row.coefficients))
+		for _, j := range sortedIndices(row.coefficients) {
+			terms = append(terms, NewTerm(s.RowScale[i]*row.coefficients[j]*s.ColumnScale[j], s.variables[j]))
+		}
+		c := model.AddNamedConstraint(row.name, NewExpression(terms), row.constraintType, s.RowScale[i]*row.rightHandSide)
+		// A unit of violation of the scaled row is 1/RowScale units of the original
+		c.Elastic, c.Penalty = row.elastic, row.penalty/s.RowScale[i]
+		c.Range = s.RowScale[i] * row.width
+	}
+
+	s.Model = &model
+}
+
+// Unscale Map the result of solving the scaled Model back to the original linear program. Values are multiplied by
+// their column scale, reduced costs divided by it, and duals multiplied by their row scale. Activities and slacks are
+// recalculated for the original constraints.
+func (s *Scaled) Unscale(scaled *Result) *Result {
+	r := &Result{
+		status:          scaled.status,
+		objectiveValue:  scaled.objectiveValue,
+		iterations:      scaled.iterations,
+		solveTime:       scaled.solveTime,
+		variables:       append([]LpVariable{}, s.variables...),
+		variableIndex:   make(map[string]int, len(s.variables)),
+		values:          make([]float64, len(s.variables)),
+		reducedCosts:    make([]float64, len(s.variables)),
+		constraints:     make([]string, len(s.rows)),
+		constraintIndex: make(map[string]int, len(s.rows)),
+		activities:      make([]float64, len(s.rows)),
+		slacks:          make([]float64, len(s.rows)),
+		duals:           make([]float64, len(s.rows)),
+		violations:      make([]float64, len(s.rows)),
+	}
+
+	for j, v := range r.variables {
+		r.variableIndex[v.Name] = j
+		r.values[j] = scaled.values[j] * s.ColumnScale[j]
+		r.reducedCosts[j] = scaled.reducedCosts[j] / s.ColumnScale[j]
+	}
+	for i, row := range s.rows {
+		r.constraints[i] = row.name
+		r.constraintIndex[row.name] = i
+		r.duals[i] = scaled.duals[i] * s.RowScale[i]
+		for j, a := range row.coefficients {
+			r.activities[i] += a * r.values[j]
+		}
+		r.slacks[i] = row.rightHandSide - r.activities[i]
+		if row.elastic {
+			r.violations[i] = violation(r.activities[i], row.constraintType, row.rightHandSide)
+		}
+	}
+
+	return r
+}
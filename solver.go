@@ -0,0 +1,226 @@
+package gulp
+
+import "time"
+
+// SolverOption Configure a call to Solve
+type SolverOption func(*solverConfig)
+
+// IterationObserver Called with each iteration of the simplex method, before its pivot or bound move is made
+type IterationObserver func(Iteration)
+
+type solverConfig struct {
+	observers      []IterationObserver
+	trace          *Trace
+	exact          bool
+	presolve       bool
+	scaling        ScalingMethod
+	tolerance      float64
+	iterationLimit int
+	timeLimit      time.Duration
+	objectiveMode  ObjectiveMode
+}
+
+// simplexTableau The operations the simplex loop needs, implemented by Tableau and RationalTableau
+type simplexTableau interface {
+	IsOptimal() bool
+	IsFeasible() bool
+	pivotColumn() int
+	blandColumn() int
+	boundStep(pivotColumnIndex int) bool
+	pivotRow(pivotColumnIndex int) (int, bool)
+	blandRow(pivotColumnIndex int) (int, bool)
+	value() float64
+	pivotOn(pivotRowIndex, pivotColumnIndex int)
+	artificialPivot() (int, int, bool)
+	phaseOne() simplexTableau
+	snapshot() *Tableau
+}
+
+// cyclingLimit The number of pivots in a row that fail to improve the objective, whether degenerate or lost to
+// round-off against big M, after which the simplex method follows Bland's rule until a pivot improves it again
+const cyclingLimit = 50
+
+// newSolverConfig Apply the options over the default configuration
+func newSolverConfig(options []SolverOption) solverConfig {
+	config := solverConfig{
+		tolerance:      DefaultTolerance,
+		iterationLimit: DefaultIterationLimit,
+	}
+	for _, option := range options {
+		option(&config)
+	}
+	return config
+}
+
+// WithObserver Call the observer with every iteration of the solve
+func WithObserver(observer IterationObserver) SolverOption {
+	return func(config *solverConfig) {
+		config.observers = append(config.observers, observer)
+	}
+}
+
+// WithTrace Record every iteration of the solve, along with the final tableau and status, into the trace
+func WithTrace(trace *Trace) SolverOption {
+	return func(config *solverConfig) {
+		config.trace = trace
+	}
+}
+
+// WithTolerance Treat values within tolerance of zero as zero when choosing pivots and checking optimality
+func WithTolerance(tolerance float64) SolverOption {
+	return func(config *solverConfig) {
+		config.tolerance = tolerance
+	}
+}
+
+// WithIterationLimit Stop with LpStatusIterationLimit after the given number of iterations, pivots and bound moves
+func WithIterationLimit(limit int) SolverOption {
+	return func(config *solverConfig) {
+		config.iterationLimit = limit
+	}
+}
+
+// WithTimeLimit Stop with LpStatusTimeLimit once the solve has taken longer than the limit, zero means no limit
+func WithTimeLimit(limit time.Duration) SolverOption {
+	return func(config *solverConfig) {
+		config.timeLimit = limit
+	}
+}
+
+// runSimplex Pivot the tableau until it is optimal, returning the status and the number of iterations made, counting
+// both pivots and bound moves
+func runSimplex(tableau simplexTableau, config solverConfig) (LpStatus, int) {
+	watched := len(config.observers) > 0 || config.trace != nil
+	record := func(iteration Iteration) {
+		for _, observer := range config.observers {
+			observer(iteration)
+		}
+		if config.trace != nil {
+			config.trace.Iterations = append(config.trace.Iterations, iteration)
+		}
+	}
+	start := time.Now()
+
+	status := LpStatusOptimal
+	iterations, stalled := 0, 0
+	for {
+		var pivotRowIndex, pivotColumnIndex int
+		if tableau.IsOptimal() {
+			// An artificial variable left in the basis at zero would price its row at big M, so it is pivoted out,
+			// which may leave the tableau to optimise again
+			ok := false
+			if tableau.IsFeasible() {
+				pivotRowIndex, pivotColumnIndex, ok = tableau.artificialPivot()
+			}
+			if !ok {
+				break
+			}
+		} else {
+			if iterations >= config.iterationLimit {
+				status = LpStatusIterationLimit
+				break
+			}
+			if config.timeLimit > 0 && time.Since(start) > config.timeLimit {
+				status = LpStatusTimeLimit
+				break
+			}
+			bland := stalled >= cyclingLimit
+			if stalled == cyclingLimit && !tableau.IsFeasible() && !canBeFeasible(tableau.phaseOne(), config.iterationLimit) {
+				// Big M swamps the objective while artificial variables are basic, so a stall may be round-off on a
+				// problem whose constraints cannot be met
+				status = LpStatusInfeasible
+				break
+			}
+			pivotColumnIndex = tableau.pivotColumn()
+			if bland {
+				pivotColumnIndex = tableau.blandColumn()
+			}
+			var before *Tableau
+			if watched {
+				before = tableau.snapshot()
+			}
+			if tableau.boundStep(pivotColumnIndex) {
+				// The slack of a range constraint moved to the other end of its range without a pivot. This improves
+				// the objective, so it cannot repeat forever.
+				if watched {
+					record(Iteration{
+						Number:      iterations + 1,
+						Kind:        IterationBound,
+						Entering:    before.NamesRow[pivotColumnIndex],
+						PivotRow:    -1,
+						PivotColumn: pivotColumnIndex,
+						Ratios:      before.ratios(pivotColumnIndex),
+						Tableau:     before,
+					})
+				}
+				iterations++
+				stalled = 0
+				continue
+			}
+			var ok bool
+			pivotRowIndex, ok = tableau.pivotRow(pivotColumnIndex)
+			if bland {
+				pivotRowIndex, ok = tableau.blandRow(pivotColumnIndex)
+			}
+			if !ok {
+				// The column is only a ray of the problem if the constraints can be met at all
+				status = LpStatusUnbounded
+				if !tableau.IsFeasible() && !canBeFeasible(tableau.phaseOne(), config.iterationLimit) {
+					status = LpStatusInfeasible
+				}
+				break
+			}
+		}
+
+		if watched {
+			snapshot := tableau.snapshot()
+			record(Iteration{
+				Number:       iterations + 1,
+				Kind:         IterationPivot,
+				Entering:     snapshot.NamesRow[pivotColumnIndex],
+				Leaving:      snapshot.BasisNames[pivotRowIndex],
+				PivotRow:     pivotRowIndex,
+				PivotColumn:  pivotColumnIndex,
+				PivotElement: snapshot.ConstraintRows[pivotRowIndex].Values[pivotColumnIndex],
+				Ratios:       snapshot.ratios(pivotColumnIndex),
+				Tableau:      snapshot,
+			})
+		}
+
+		value := tableau.value()
+		tableau.pivotOn(pivotRowIndex, pivotColumnIndex)
+		iterations++
+		if tableau.value() > value {
+			stalled = 0
+		} else {
+			stalled++
+		}
+	}
+	if status == LpStatusOptimal && !tableau.IsFeasible() {
+		status = LpStatusInfeasible
+	}
+
+	if config.trace != nil {
+		config.trace.Final = tableau.snapshot()
+		config.trace.Status = status
+	}
+	return status, iterations
+}
+
+// canBeFeasible Pivot a phase one tableau, see phaseOne, to its optimum and check that no artificial variable is left
+// at a positive value. Pivots follow Bland's rule, so cannot cycle, but it gives up and reports feasible after the
+// iteration limit.
+func canBeFeasible(tableau simplexTableau, iterationLimit int) bool {
+	for iterations := 0; !tableau.IsOptimal() && iterations < iterationLimit; iterations++ {
+		pivotColumnIndex := tableau.blandColumn()
+		if tableau.boundStep(pivotColumnIndex) {
+			continue
+		}
+		pivotRowIndex, ok := tableau.blandRow(pivotColumnIndex)
+		if !ok {
+			break
+		}
+		tableau.pivotOn(pivotRowIndex, pivotColumnIndex)
+	}
+	return tableau.IsFeasible()
+}
@@ -0,0 +1,149 @@
+package gulp
+
+import (
+	"fmt"
+	"math"
+)
+
+// LpSOSType How many adjacent variables of a special ordered set may be non-zero
+type LpSOSType int
+
+const (
+	LpSOS1 = LpSOSType(1)
+	LpSOS2 = LpSOSType(2)
+)
+
+// LpSOS A special ordered set of variables, see AddNamedSOS
+type LpSOS struct {
+	Name      string
+	Type      LpSOSType
+	Variables []LpVariable // In order of weight
+	Weights   []float64    // Strictly increasing, used to choose where to branch
+}
+
+// AddNamedSOS Add a special ordered set under the given name and return a handle to it. At most one variable of an
+// SOS1 may be non-zero, and at most two of an SOS2, which must be next to each other in the order of the weights. The
+// weights must be strictly increasing, nil numbers the variables 1, 2, ... The sets are enforced by branching, so a
+// model with any is solved by branch and bound.
+func (lp *LinearProgram) AddNamedSOS(name string, sosType LpSOSType, variables []LpVariable, weights []float64) *LpSOS {
+	if len(lp.ObjectiveFunction.Terms) == 0 {
+		panic("Objective function not set")
+	}
+	if name == "" {
+		panic("Set name must not be empty")
+	}
+	if lp.SpecialOrderedSet(name) != nil {
+		panic(fmt.Sprintf("Set %q already exists", name))
+	}
+	if sosType != LpSOS1 && sosType != LpSOS2 {
+		panic(fmt.Sprintf("Set %q: unknown type %v", name, sosType))
+	}
+	if len(variables) == 0 {
+		panic(fmt.Sprintf("Set %q has no variables", name))
+	}
+	if weights == nil {
+		weights = make([]float64, len(variables))
+		for k := range weights {
+			weights[k] = float64(k + 1)
+		}
+	}
+	if len(weights) != len(variables) {
+		panic(fmt.Sprintf("Set %q needs one weight per variable, got %d and %d", name, len(weights), len(variables)))
+	}
+	seen := make(map[string]bool, len(variables))
+	for k, v := range variables {
+		if seen[v.Name] {
+			panic(fmt.Sprintf("Set %q has variable %q more than once", name, v.Name))
+		}
+		seen[v.Name] = true
+		if math.IsNaN(weights[k]) || (k > 0 && !(weights[k] > weights[k-1])) {
+			panic(fmt.Sprintf("Set %q: weights must be strictly increasing, got %v", name, weights))
+		}
+	}
+
+	for _, v := range variables {
+		lp.registerVariable(v)
+	}
+	s := &LpSOS{
+		Name:      name,
+		Type:      sosType,
+		Variables: append([]LpVariable{}, variables...),
+		Weights:   append([]float64{}, weights...),
+	}
+	lp.SpecialOrderedSets = append(lp.SpecialOrderedSets, s)
+	return s
+}
+
+// AddSOS Add an automatically named special ordered set, see AddNamedSOS
+func (lp *LinearProgram) AddSOS(sosType LpSOSType, variables []LpVariable, weights []float64) *LinearProgram {
+	lp.AddNamedSOS(lp.nextSOSName(), sosType, variables, weights)
+	return lp
+}
+
+// SpecialOrderedSet Look up a special ordered set by name, returning nil if there is no such set
+func (lp *LinearProgram) SpecialOrderedSet(name string) *LpSOS {
+	for _, s := range lp.SpecialOrderedSets {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// nextSOSName Generate an unused name of the form sos1, sos2, ...
+func (lp *LinearProgram) nextSOSName() string {
+	for i := len(lp.SpecialOrderedSets) + 1; ; i++ {
+		name := fmt.Sprintf("sos%d", i)
+		if lp.SpecialOrderedSet(name) == nil {
+			return name
+		}
+	}
+}
+
+// violation Get the largest value outside the run of Type variables that leaves the least outside, zero when the set
+// holds
+func (s *LpSOS) violation(value func(LpVariable) float64) float64 {
+	least := math.Inf(1)
+	for start := 0; start+int(s.Type) <= len(s.Variables) || start == 0; start++ {
+		outside := 0.0
+		for k, v := range s.Variables {
+			if k < start || k >= start+int(s.Type) {
+				outside = math.Max(outside, value(v))
+			}
+		}
+		least = math.Min(least, outside)
+	}
+	return least
+}
+
+// split Choose where to branch on a violated set, at the weighted average of its non-zero variables. One branch keeps
+// the variables up to the returned index and the other those from the index after it for an SOS1, or from the index
+// itself for an SOS2, so the current solution is in neither.
+func (s *LpSOS) split(value func(LpVariable) float64, tolerance float64) int {
+	first, last := -1, -1
+	total, weighted := 0.0, 0.0
+	for k, v := range s.Variables {
+		if x := value(v); x > tolerance {
+			if first < 0 {
+				first = k
+			}
+			last = k
+			total += x
+			weighted += x * s.Weights[k]
+		}
+	}
+	average := weighted / total
+
+	split := first
+	for split+1 < len(s.Variables) && s.Weights[split+1] <= average {
+		split++
+	}
+	// Both branches must rule out a non-zero variable
+	if split > last-1 {
+		split = last - 1
+	}
+	if s.Type == LpSOS2 && split < first+1 {
+		split = first + 1
+	}
+	return split
+}
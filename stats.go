@@ -0,0 +1,175 @@
+package gulp
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"text/tabwriter"
+)
+
+// Values at least this large are close enough to the big-M penalty to distort the solve
+const bigMWarning = bigM * 1e-6
+
+// Coefficient ranges wider than this ratio are likely to cause round-off trouble
+const rangeWarning = 1e8
+
+// Stats A summary of the size and conditioning of a linear program, see LinearProgram.Stats
+type Stats struct {
+	Variables    int
+	Constraints  int
+	LessEqual    int
+	GreaterEqual int
+	Equal        int
+	Ranged       int
+
+	NonZeros int
+	Density  float64 // NonZeros as a fraction of Variables * Constraints
+
+	Coefficients  CoefficientRange // The constraint matrix
+	Objective     CoefficientRange
+	RightHandSide CoefficientRange
+
+	// Variables by their bounds and category. Every variable is non-negative, and a variable is bounded when a
+	// singleton row or the binary category gives it an upper bound.
+	Bounded int
+	Integer int // Integer and binary variables
+
+	// Warnings Values that are likely to cause trouble when solving, in model order
+	Warnings []string
+}
+
+// Stats Count the variables, constraints and non-zeros of the linear program and check its coefficients for values
+// that are likely to cause trouble: coefficients large enough to collide with the big-M penalty, coefficients so small
+// the solver treats them as zero, wide coefficient ranges, and empty rows and columns.
+func (lp *LinearProgram) Stats() *Stats {
+	s := &Stats{Variables: len(lp.variables), Constraints: len(lp.Constraints)}
+
+	objective := make([]float64, len(lp.variables))
+	for _, t := range lp.userObjective() {
+		objective[lp.variableIndex[t.Variable.Name]] += t.Coefficient
+	}
+	for j, c := range objective {
+		s.Objective.add(c)
+		s.checkValue(c, fmt.Sprintf("Objective coefficient of %v", lp.variables[j].Name))
+	}
+	if s.Objective.Max == 0 {
+		s.warn("The objective has no non-zero coefficients")
+	}
+
+	used := make([]bool, len(lp.variables))
+	bounded := make([]bool, len(lp.variables))
+	for _, row := range lp.sparseRows() {
+		switch {
+		case row.width != 0:
+			s.Ranged++
+		case row.constraintType == LpConstraintLE:
+			s.LessEqual++
+		case row.constraintType == LpConstraintGE:
+			s.GreaterEqual++
+		case row.constraintType == LpConstraintEQ:
+			s.Equal++
+		}
+
+		s.RightHandSide.add(row.rightHandSide)
+		s.checkValue(row.rightHandSide, fmt.Sprintf("Row %v: right hand side", row.name))
+		if row.width != 0 {
+			s.RightHandSide.add(row.rightHandSide - row.width)
+			s.checkValue(row.rightHandSide-row.width, fmt.Sprintf("Row %v: lower bound", row.name))
+		}
+
+		if len(row.coefficients) == 0 {
+			s.warn("Row %v has no non-zero coefficients", row.name)
+		}
+		for _, j := range sortedIndices(row.coefficients) {
+			a := row.coefficients[j]
+			s.NonZeros++
+			s.Coefficients.add(a)
+			s.checkValue(a, fmt.Sprintf("Row %v: coefficient of %v", row.name, lp.variables[j].Name))
+			used[j] = true
+
+			// A singleton row gives an upper bound when it limits the variable from above
+			if len(row.coefficients) == 1 && (row.constraintType == LpConstraintEQ || row.width != 0 || float64(row.constraintType)*a < 0) {
+				bounded[j] = true
+			}
+		}
+	}
+
+	for j, v := range lp.variables {
+		switch lp.Category(v) {
+		case LpBinary:
+			bounded[j] = true
+			s.Integer++
+		case LpInteger:
+			s.Integer++
+		}
+		if bounded[j] {
+			s.Bounded++
+		}
+		if !used[j] {
+			s.warn("Variable %v appears in no constraint", v.Name)
+		}
+	}
+
+	if s.Variables > 0 && s.Constraints > 0 {
+		s.Density = float64(s.NonZeros) / float64(s.Variables*s.Constraints)
+	}
+	if s.Coefficients.Ratio() > rangeWarning {
+		s.warn("Coefficients range over a ratio of %v, consider WithScaling", formatNumber(s.Coefficients.Ratio()))
+	}
+	return s
+}
+
+// String Format the statistics as a plain text report
+func (s *Stats) String() string {
+	sb := strings.Builder{}
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Variables:\t%d (%d bounded, %d integer)\n", s.Variables, s.Bounded, s.Integer)
+	if s.Ranged > 0 {
+		fmt.Fprintf(tw, "Constraints:\t%d (%d <=, %d >=, %d =, %d ranged)\n", s.Constraints, s.LessEqual, s.GreaterEqual, s.Equal, s.Ranged)
+	} else {
+		fmt.Fprintf(tw, "Constraints:\t%d (%d <=, %d >=, %d =)\n", s.Constraints, s.LessEqual, s.GreaterEqual, s.Equal)
+	}
+	fmt.Fprintf(tw, "Non-zeros:\t%d (density %v%%)\n", s.NonZeros, formatNumber(math.Round(s.Density*1000)/10))
+	fmt.Fprintf(tw, "Coefficients:\t%v\n", s.Coefficients)
+	fmt.Fprintf(tw, "Objective:\t%v\n", s.Objective)
+	fmt.Fprintf(tw, "Right hand side:\t%v\n", s.RightHandSide)
+	_ = tw.Flush()
+
+	if len(s.Warnings) > 0 {
+		sb.WriteString("\nWarnings:\n")
+		for _, w := range s.Warnings {
+			sb.WriteString("  " + w + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// add Widen the range to include the absolute value of v, zeros are ignored
+func (c *CoefficientRange) add(v float64) {
+	v = math.Abs(v)
+	if v == 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+		return
+	}
+	if c.Min == 0 || v < c.Min {
+		c.Min = v
+	}
+	if v > c.Max {
+		c.Max = v
+	}
+}
+
+// checkValue Warn about a value that is not finite, collides with the big-M penalty or falls below the tolerance
+func (s *Stats) checkValue(v float64, what string) {
+	switch {
+	case math.IsNaN(v) || math.IsInf(v, 0):
+		s.warn("%v is %v", what, v)
+	case math.Abs(v) >= bigMWarning:
+		s.warn("%v is %v, close to the big-M penalty of %v", what, formatNumber(v), formatNumber(bigM))
+	case v != 0 && math.Abs(v) < DefaultTolerance:
+		s.warn("%v is %v, below the solver tolerance of %v", what, formatNumber(v), formatNumber(DefaultTolerance))
+	}
+}
+
+func (s *Stats) warn(format string, args ...interface{}) {
+	s.Warnings = append(s.Warnings, fmt.Sprintf(format, args...))
+}
@@ -1,10 +1,14 @@
 package gulp
 
 import (
+	"errors"
 	"fmt"
 	"math"
 )
 
+// DefaultTolerance Values within this distance of zero are treated as zero by the simplex method, see WithTolerance
+const DefaultTolerance = 1e-9
+
 type Tableau struct {
 	// Rows
 	NamesRow       []string
@@ -26,6 +30,13 @@ type Tableau struct {
 	Sense LpSense
 
 	Variables []LpVariable
+
+	tolerance float64
+
+	// Upper bounds on the slacks of range constraints, nil when there are none. A complemented column holds the
+	// distance of its variable below the upper bound, so every column is at zero while it is out of the basis.
+	upper        []float64
+	complemented []bool
 }
 
 type Row struct {
@@ -36,32 +47,36 @@ type Column struct {
 	Values []float64
 }
 
+// NewTableau Build the initial tableau for the standard form of the linear program
 func NewTableau(lp *LinearProgram) *Tableau {
-	tableau := &Tableau{}
+	objective, constraints := lp.standardForm()
+	return newTableau(objective, constraints, lp.Sense)
+}
+
+func newTableau(objective LpExpression, constraints []_constraint, sense LpSense) *Tableau {
+	tableau := &Tableau{tolerance: DefaultTolerance}
 
 	// Create the names row and objective row
-	tableau.NamesRow = make([]string, len(lp.ObjectiveFunction.Terms))
-	tableau.ObjectiveRow = Row{Values: make([]float64, len(lp.ObjectiveFunction.Terms))}
-	for i, v := range lp.ObjectiveFunction.Terms {
+	tableau.NamesRow = make([]string, len(objective.Terms))
+	tableau.ObjectiveRow = Row{Values: make([]float64, len(objective.Terms))}
+	columns := make(map[string]int, len(objective.Terms))
+	for i, v := range objective.Terms {
 		tableau.NamesRow[i] = v.Variable.Name
 		tableau.ObjectiveRow.Values[i] = v.Coefficient
+		columns[v.Variable.Name] = i
 	}
 
-	tableau.ConstraintRows = make([]Row, len(lp.Constraints))
-	tableau.BasisNames = make([]string, len(lp.Constraints))
-	tableau.BasisColumn = Column{Values: make([]float64, len(lp.Constraints))}
-	tableau.BColumn = Column{Values: make([]float64, len(lp.Constraints))}
+	tableau.ConstraintRows = make([]Row, len(constraints))
+	tableau.BasisNames = make([]string, len(constraints))
+	tableau.BasisColumn = Column{Values: make([]float64, len(constraints))}
+	tableau.BColumn = Column{Values: make([]float64, len(constraints))}
 
 	// Create the constraint rows
-	for i, v := range lp.Constraints {
-		tableau.ConstraintRows[i] = Row{Values: make([]float64, len(lp.ObjectiveFunction.Terms))}
-		tableau.BColumn.Values[i] = lp.Constraints[i].RightHandSide
+	for i, v := range constraints {
+		tableau.ConstraintRows[i] = Row{Values: make([]float64, len(objective.Terms))}
+		tableau.BColumn.Values[i] = constraints[i].RightHandSide
 		for _, p := range v.Terms {
-			for k, o := range lp.ObjectiveFunction.Terms {
-				if o.Variable.Name == p.Variable.Name {
-					tableau.ConstraintRows[i].Values[k] = p.Coefficient
-				}
-			}
+			tableau.ConstraintRows[i].Values[columns[p.Variable.Name]] += p.Coefficient
 		}
 	}
 
@@ -88,8 +103,8 @@ func NewTableau(lp *LinearProgram) *Tableau {
 	}
 
 	// Create the Z row
-	tableau.ZRow = Row{Values: make([]float64, len(lp.ObjectiveFunction.Terms))}
-	tableau.CZRow = Row{Values: make([]float64, len(lp.ObjectiveFunction.Terms))}
+	tableau.ZRow = Row{Values: make([]float64, len(objective.Terms))}
+	tableau.CZRow = Row{Values: make([]float64, len(objective.Terms))}
 	for i, r := range tableau.ConstraintRows {
 		for j, v := range r.Values {
 			tableau.ZRow.Values[j] += v * tableau.BasisColumn.Values[i]
@@ -107,63 +122,310 @@ func NewTableau(lp *LinearProgram) *Tableau {
 	}
 
 	// Add all the variables to the tableau
-	for _, v := range lp.ObjectiveFunction.Terms {
+	for _, v := range objective.Terms {
 		tableau.Variables = append(tableau.Variables, v.Variable)
 	}
 
-	tableau.Sense = lp.hiddenSense
+	// Bound the slacks of range constraints
+	for _, c := range constraints {
+		if c.Range == 0 {
+			continue
+		}
+		if tableau.upper == nil {
+			tableau.upper = make([]float64, len(tableau.NamesRow))
+			tableau.complemented = make([]bool, len(tableau.NamesRow))
+			for j := range tableau.upper {
+				tableau.upper[j] = math.Inf(1)
+			}
+		}
+		for _, p := range c.Terms {
+			if p.Variable.IsSlack {
+				tableau.upper[columns[p.Variable.Name]] = c.Range
+			}
+		}
+	}
+
+	tableau.Sense = sense
 	return tableau
 }
 
-// TODO: Implement the String() method for the Tableau struct
-func (t *Tableau) String() string {
-	fmt.Println("Not implemented")
-	return "Not implemented"
+// Pivot Perform a single iteration of the simplex method, returning false if no pivot was made because the tableau
+// is already optimal or the pivot column is unbounded. When the slack of a range constraint reaches the other end of
+// its range first, it is moved there instead of pivoting.
+func (t *Tableau) Pivot() bool {
+	if t.IsOptimal() {
+		return false
+	}
+	pivotColumnIndex := t.pivotColumn()
+	if t.boundStep(pivotColumnIndex) {
+		return true
+	}
+	pivotRowIndex, ok := t.pivotRow(pivotColumnIndex)
+	if !ok {
+		return false
+	}
+	t.pivotOn(pivotRowIndex, pivotColumnIndex)
+	return true
+}
+
+// Errors returned by PivotAt when a pivot is rejected
+var (
+	ErrPivotOutOfRange = errors.New("pivot position is outside the tableau")
+	ErrPivotZero       = errors.New("pivot element is zero")
+	ErrPivotInfeasible = errors.New("pivot would make the tableau infeasible")
+)
+
+// PivotAt Bring the variable in the given column into the basis in place of the variable in the given row. A bounded
+// variable leaving on a negative element leaves at its upper bound. The pivot is rejected, leaving the tableau
+// unchanged, if the element is zero or if any variable would become negative or pass its upper bound, as happens when
+// the row does not have the smallest ratio in RatioTest or the entering variable reaches its own upper bound first.
+func (t *Tableau) PivotAt(pivotRowIndex, pivotColumnIndex int) error {
+	if pivotRowIndex < 0 || pivotRowIndex >= len(t.ConstraintRows) || pivotColumnIndex < 0 || pivotColumnIndex >= len(t.NamesRow) {
+		return fmt.Errorf("%w: row %d, column %d", ErrPivotOutOfRange, pivotRowIndex, pivotColumnIndex)
+	}
+	element := t.ConstraintRows[pivotRowIndex].Values[pivotColumnIndex]
+	if math.Abs(element) <= t.tolerance {
+		return fmt.Errorf("%w: %v in row %v", ErrPivotZero, t.NamesRow[pivotColumnIndex], t.BasisNames[pivotRowIndex])
+	}
+
+	// Check the values the pivot would produce before changing anything
+	leaving := t.ColumnIndex(t.BasisNames[pivotRowIndex])
+	theta := t.BColumn.Values[pivotRowIndex] / element
+	atUpper := element < 0 && !math.IsInf(t.upperBound(leaving), 1)
+	if atUpper {
+		theta = (t.upperBound(leaving) - t.BColumn.Values[pivotRowIndex]) / -element
+	}
+	for i, b := range t.BColumn.Values {
+		name, value, upper := t.NamesRow[pivotColumnIndex], theta, t.upperBound(pivotColumnIndex)
+		if i != pivotRowIndex {
+			name, value, upper = t.BasisNames[i], b-t.ConstraintRows[i].Values[pivotColumnIndex]*theta, t.upperBound(t.ColumnIndex(t.BasisNames[i]))
+		}
+		if value < -t.tolerance {
+			return fmt.Errorf("%w: %v would be %v", ErrPivotInfeasible, name, formatNumber(value))
+		}
+		if value > upper+t.tolerance {
+			return fmt.Errorf("%w: %v would be %v, above its upper bound %v", ErrPivotInfeasible, name, formatNumber(value), formatNumber(upper))
+		}
+	}
+
+	if atUpper {
+		t.complement(leaving)
+	}
+	t.pivotOn(pivotRowIndex, pivotColumnIndex)
+	return nil
 }
 
-// Pivot TODO: Take the largest negative value in the CZRow as the pivot column
-func (t *Tableau) Pivot() {
-	// Find the pivot column
-	pivotColumnIndex := 0
+// CandidateColumns Get the columns that would improve the objective if they entered the basis, those with a positive
+// C-Z entry. A complemented column holds the distance of a bounded variable below its upper bound, so a positive entry
+// there means lowering the variable from the bound. The tableau is optimal when there are none.
+func (t *Tableau) CandidateColumns() []int {
+	var columns []int
 	for i, v := range t.CZRow.Values {
-		boolean := v > t.CZRow.Values[pivotColumnIndex]
+		if v > t.tolerance {
+			columns = append(columns, i)
+		}
+	}
+	return columns
+}
 
-		if boolean {
+// RatioTest Get how far the variable in the given column can rise before the basic variable of each row leaves: the
+// ratio of the right hand side to a positive entry, or for a bounded basic variable and a negative entry, the distance
+// to its upper bound over the size of the entry. Rows that do not limit the column get +Inf, and the row with the
+// smallest ratio leaves the basis. If the entering variable reaches its own upper bound first no row leaves, and Pivot
+// moves it to that bound instead.
+func (t *Tableau) RatioTest(pivotColumnIndex int) []float64 {
+	if pivotColumnIndex < 0 || pivotColumnIndex >= len(t.NamesRow) {
+		panic(fmt.Sprintf("Column %d is outside the tableau", pivotColumnIndex))
+	}
+	ratios := t.ratios(pivotColumnIndex)
+	for i, name := range t.BasisNames {
+		a := t.ConstraintRows[i].Values[pivotColumnIndex]
+		if upper := t.upperBound(t.ColumnIndex(name)); a < -t.tolerance && !math.IsInf(upper, 1) {
+			ratios[i] = (upper - t.BColumn.Values[i]) / -a
+		}
+	}
+	return ratios
+}
+
+// upperBound Get the upper bound on the variable in the given column, +Inf if it has none
+func (t *Tableau) upperBound(column int) float64 {
+	if t.upper == nil || column < 0 {
+		return math.Inf(1)
+	}
+	return t.upper[column]
+}
+
+// ColumnIndex Get the column of the named variable, or -1 if there is no such column
+func (t *Tableau) ColumnIndex(name string) int {
+	for i, v := range t.NamesRow {
+		if v == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// RowIndex Get the row in which the named variable is basic, or -1 if it is not in the basis
+func (t *Tableau) RowIndex(name string) int {
+	for i, v := range t.BasisNames {
+		if v == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// pivotColumn Find the column with the largest value in the CZRow
+func (t *Tableau) pivotColumn() int {
+	pivotColumnIndex := 0
+	for i, v := range t.CZRow.Values {
+		if v > t.CZRow.Values[pivotColumnIndex] {
 			pivotColumnIndex = i
 		}
 	}
+	return pivotColumnIndex
+}
 
-	// Find the pivot row
-	pivotRowIndex := 0
-	// TODO: this might need to change depending on the sense of the problem
-	optimumColumnRatio := math.Inf(1)
+// ratios Calculate the ratio test for the given column, rows with no positive entry in the column get +Inf
+func (t *Tableau) ratios(pivotColumnIndex int) []float64 {
+	ratios := make([]float64, len(t.BColumn.Values))
 	for i, v := range t.BColumn.Values {
-		if t.ConstraintRows[i].Values[pivotColumnIndex] > 0 {
-			ratio := v / t.ConstraintRows[i].Values[pivotColumnIndex]
-			boolean := ratio < optimumColumnRatio && ratio > 0
-			if boolean {
-				optimumColumnRatio = ratio
-				pivotRowIndex = i
+		ratios[i] = math.Inf(1)
+		if t.ConstraintRows[i].Values[pivotColumnIndex] > t.tolerance {
+			ratios[i] = v / t.ConstraintRows[i].Values[pivotColumnIndex]
+		}
+	}
+	return ratios
+}
+
+// pivotRow Find the row with the smallest ratio in the given column, returning false if no row limits the column, in
+// which case the problem is unbounded
+func (t *Tableau) pivotRow(pivotColumnIndex int) (int, bool) {
+	pivotRowIndex := -1
+	optimumColumnRatio := math.Inf(1)
+	for i, ratio := range t.ratios(pivotColumnIndex) {
+		if ratio < optimumColumnRatio {
+			optimumColumnRatio = ratio
+			pivotRowIndex = i
+		}
+	}
+	return pivotRowIndex, pivotRowIndex >= 0
+}
+
+// blandColumn Find the first column with a positive value in the CZRow, the entering rule of Bland's rule
+func (t *Tableau) blandColumn() int {
+	for i, v := range t.CZRow.Values {
+		if v > t.tolerance {
+			return i
+		}
+	}
+	return t.pivotColumn()
+}
+
+// blandRow Find the row with the smallest ratio in the given column, breaking ties by the first basic variable in
+// column order, the leaving rule of Bland's rule
+func (t *Tableau) blandRow(pivotColumnIndex int) (int, bool) {
+	pivotRowIndex, ok := t.pivotRow(pivotColumnIndex)
+	if !ok {
+		return pivotRowIndex, ok
+	}
+	ratios := t.ratios(pivotColumnIndex)
+	optimumColumnRatio := ratios[pivotRowIndex]
+	for i, ratio := range ratios {
+		if ratio <= optimumColumnRatio+t.tolerance && t.ColumnIndex(t.BasisNames[i]) < t.ColumnIndex(t.BasisNames[pivotRowIndex]) {
+			pivotRowIndex = i
+		}
+	}
+	return pivotRowIndex, true
+}
+
+// value Get the value of the objective in the tableau
+func (t *Tableau) value() float64 {
+	return t.TableauValue
+}
+
+// boundStep Keep the bounded variables within their bounds as the variable in the given column enters. Returns true if
+// the entering variable reaches its own upper bound before any basic variable leaves, in which case it is complemented
+// and stays out of the basis. A bounded basic variable that would pass its upper bound first is complemented, so the
+// ratio test then picks its row.
+func (t *Tableau) boundStep(pivotColumnIndex int) bool {
+	if t.upper == nil {
+		return false
+	}
+	step := math.Inf(1)
+	for _, ratio := range t.ratios(pivotColumnIndex) {
+		step = math.Min(step, ratio)
+	}
+	row, rise := -1, math.Inf(1)
+	for i, name := range t.BasisNames {
+		a := t.ConstraintRows[i].Values[pivotColumnIndex]
+		upper := t.upper[t.ColumnIndex(name)]
+		if a < -t.tolerance && !math.IsInf(upper, 1) {
+			if ratio := (upper - t.BColumn.Values[i]) / -a; ratio < rise {
+				row, rise = i, ratio
+			}
+		}
+	}
+
+	if upper := t.upper[pivotColumnIndex]; !math.IsInf(upper, 1) && upper <= math.Min(step, rise) {
+		t.complement(pivotColumnIndex)
+		return true
+	}
+	if rise < step {
+		t.complement(t.ColumnIndex(t.BasisNames[row]))
+	}
+	return false
+}
+
+// artificialPivot Find a pivot that takes an artificial variable left in the basis at zero out of it, on the first
+// other column with a non-zero entry in its row. The pivot is degenerate, so the solution is unchanged. An artificial
+// variable whose row has no such entry sits on a redundant row, and is priced at zero instead so that big M does not
+// reach the duals. Returns false if there is no pivot to make.
+func (t *Tableau) artificialPivot() (int, int, bool) {
+	for i, name := range t.BasisNames {
+		if !t.isArtificial(name) || math.Abs(t.BColumn.Values[i]) > t.tolerance || t.BasisColumn.Values[i] == 0 {
+			continue
+		}
+		for j, a := range t.ConstraintRows[i].Values {
+			if math.Abs(a) > t.tolerance && !t.isArtificial(t.NamesRow[j]) {
+				return i, j, true
 			}
 		}
+		t.ObjectiveRow.Values[t.ColumnIndex(name)] = 0
+		t.BasisColumn.Values[i] = 0
+		t.update()
 	}
+	return -1, -1, false
+}
 
+// complement Replace the bounded variable in the given column by its distance below its upper bound, or back again.
+// Only the slacks of range constraints are bounded, and they cost nothing, so the objective row is unchanged.
+func (t *Tableau) complement(column int) {
+	upper := t.upper[column]
+	for i, row := range t.ConstraintRows {
+		t.BColumn.Values[i] = clean(t.BColumn.Values[i] - row.Values[column]*upper)
+		row.Values[column] = -row.Values[column]
+	}
+	// A basic variable keeps a unit column
+	if i := t.RowIndex(t.NamesRow[column]); i >= 0 {
+		for j := range t.ConstraintRows[i].Values {
+			t.ConstraintRows[i].Values[j] = -t.ConstraintRows[i].Values[j]
+		}
+		t.BColumn.Values[i] = -t.BColumn.Values[i]
+	}
+	t.complemented[column] = !t.complemented[column]
+	t.update()
+}
+
+// pivotOn Bring the variable in the given column into the basis in place of the variable in the given row
+func (t *Tableau) pivotOn(pivotRowIndex, pivotColumnIndex int) {
 	oldBasisName := t.BasisNames[pivotRowIndex]
 	// Update the basis
 	t.BasisNames[pivotRowIndex] = t.NamesRow[pivotColumnIndex]
 	t.BasisColumn.Values[pivotRowIndex] = t.ObjectiveRow.Values[pivotColumnIndex]
 
-	// If variable being replaced is artificial, remove it from the basis
-	// TODO: Have a better way to check if a variable is artificial, as this is a hack
-	boolean := false
-	for _, v := range t.Variables {
-		if v.Name == oldBasisName {
-			boolean = v.IsArtificial
-			break
-		}
-	}
-
-	if boolean {
+	// If variable being replaced is artificial, remove it from the problem so it cannot re-enter
+	if t.isArtificial(oldBasisName) {
 		for i, v := range t.NamesRow {
 			if v == oldBasisName {
 				t.ObjectiveRow.Values[i] = 0
@@ -182,44 +444,105 @@ func (t *Tableau) Pivot() {
 	for i := range pivotRow.Values {
 		pivotRow.Values[i] /= pivotRowValue
 	}
-	t.BColumn.Values[pivotRowIndex] /= pivotRowValue
+	t.BColumn.Values[pivotRowIndex] = clean(t.BColumn.Values[pivotRowIndex] / pivotRowValue)
 
 	// Update the pivot column
 	for i := range t.ConstraintRows {
 		if i != pivotRowIndex {
 			multiplier := t.ConstraintRows[i].Values[pivotColumnIndex]
 			for j := range t.ConstraintRows[i].Values {
-				t.ConstraintRows[i].Values[j] -= multiplier * pivotRow.Values[j]
+				t.ConstraintRows[i].Values[j] = clean(t.ConstraintRows[i].Values[j] - multiplier*pivotRow.Values[j])
 			}
-			t.BColumn.Values[i] -= multiplier * t.BColumn.Values[pivotRowIndex]
+			t.BColumn.Values[i] = clean(t.BColumn.Values[i] - multiplier*t.BColumn.Values[pivotRowIndex])
 		}
 	}
 
-	// Update the Z row
+	t.update()
+}
+
+// update Recalculate the Z row, CZ row and tableau value from the current basis
+func (t *Tableau) update() {
 	for i := range t.ZRow.Values {
 		val := 0.0
 		for j := range t.ConstraintRows {
 			val += t.ConstraintRows[j].Values[i] * t.BasisColumn.Values[j]
 		}
 		t.ZRow.Values[i] = val
+		t.CZRow.Values[i] = t.ObjectiveRow.Values[i] - val
 	}
 
-	// Update the objective row
-	multiplier := t.CZRow.Values[pivotColumnIndex]
-	for i := range t.CZRow.Values {
-		t.CZRow.Values[i] -= multiplier * pivotRow.Values[i]
-	}
-
-	// Update the tableau value
 	t.TableauValue = 0
 	for i, v := range t.BColumn.Values {
 		t.TableauValue += v * t.BasisColumn.Values[i]
 	}
 }
 
+// isArtificial Check whether the named tableau variable is an artificial variable
+func (t *Tableau) isArtificial(name string) bool {
+	for _, v := range t.Variables {
+		if v.Name == name {
+			return v.IsArtificial
+		}
+	}
+	return false
+}
+
+// Clone Make a deep copy of the tableau
+func (t *Tableau) Clone() *Tableau {
+	clone := *t
+	clone.NamesRow = append([]string{}, t.NamesRow...)
+	clone.ObjectiveRow = Row{Values: append([]float64{}, t.ObjectiveRow.Values...)}
+	clone.ConstraintRows = make([]Row, len(t.ConstraintRows))
+	for i, r := range t.ConstraintRows {
+		clone.ConstraintRows[i] = Row{Values: append([]float64{}, r.Values...)}
+	}
+	clone.BasisNames = append([]string{}, t.BasisNames...)
+	clone.BasisColumn = Column{Values: append([]float64{}, t.BasisColumn.Values...)}
+	clone.BColumn = Column{Values: append([]float64{}, t.BColumn.Values...)}
+	clone.ZRow = Row{Values: append([]float64{}, t.ZRow.Values...)}
+	clone.CZRow = Row{Values: append([]float64{}, t.CZRow.Values...)}
+	clone.Variables = append([]LpVariable{}, t.Variables...)
+	if t.upper != nil {
+		clone.upper = append([]float64{}, t.upper...)
+		clone.complemented = append([]bool{}, t.complemented...)
+	}
+	return &clone
+}
+
+// phaseOne Get a copy of the tableau that maximises minus the sum of the artificial variables, so its optimum is zero
+// exactly when the constraints can be met
+func (t *Tableau) phaseOne() simplexTableau {
+	p := t.Clone()
+	for j, name := range p.NamesRow {
+		p.ObjectiveRow.Values[j] = 0
+		if p.isArtificial(name) {
+			p.ObjectiveRow.Values[j] = -1
+		}
+	}
+	for i, name := range p.BasisNames {
+		p.BasisColumn.Values[i] = p.ObjectiveRow.Values[p.ColumnIndex(name)]
+	}
+	p.update()
+	return p
+}
+
+func (t *Tableau) snapshot() *Tableau {
+	return t.Clone()
+}
+
+// IsFeasible Check that no artificial variable remains in the basis at a positive value
+func (t *Tableau) IsFeasible() bool {
+	for i, v := range t.BasisNames {
+		if t.isArtificial(v) && t.BColumn.Values[i] > t.tolerance {
+			return false
+		}
+	}
+	return true
+}
+
 func (t *Tableau) IsOptimal() bool {
 	for _, v := range t.CZRow.Values {
-		if v > 0 {
+		if v > t.tolerance {
 			return false
 		}
 	}
@@ -231,25 +554,15 @@ func (t *Tableau) GetSolution() map[string]float64 {
 	for i, v := range t.BasisNames {
 		solution[v] = t.BColumn.Values[i]
 	}
+	for j, complemented := range t.complemented {
+		if complemented {
+			solution[t.NamesRow[j]] = t.upper[j] - solution[t.NamesRow[j]]
+		}
+	}
 	return solution
 }
 
+// Log Print the tableau to stdout
 func (t *Tableau) Log() {
-	// Print Debug
-	fmt.Println("Objective Names:", t.NamesRow)
-	fmt.Println("Objective Row:", t.ObjectiveRow)
-
-	for _, v := range t.ConstraintRows {
-		fmt.Println("Constraint Row:", v)
-	}
-
-	fmt.Println("Basis Names:", t.BasisNames)
-	fmt.Println("Basis Column:", t.BasisColumn)
-	fmt.Println("B Column:", t.BColumn)
-
-	fmt.Println("Z Row:", t.ZRow)
-	fmt.Println("CZ Row:", t.CZRow)
-
-	fmt.Println("Tableau Value:", t.TableauValue)
-	fmt.Println()
+	fmt.Println(t.String())
 }
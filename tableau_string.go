@@ -0,0 +1,310 @@
+package gulp
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// tableauGrid The cells of a tableau laid out for display
+type tableauGrid struct {
+	Header      []string
+	Costs       []string
+	Rows        [][]string
+	Z           []string
+	CZ          []string
+	columnCount int
+}
+
+// String Render the tableau as an aligned table
+func (t *Tableau) String() string {
+	return t.Format(false)
+}
+
+// Format Render the tableau as an aligned table, showing values as fractions such as 3/2 when fractions is set.
+// Multiples of the big-M penalty are shown as multiples of M.
+func (t *Tableau) Format(fractions bool) string {
+	return t.grid(fractions).String()
+}
+
+// String Render the grid as an aligned table
+func (grid tableauGrid) String() string {
+	widths := make([]int, grid.columnCount)
+	for _, row := range grid.all() {
+		for i, cell := range row {
+			if len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+
+	sb := strings.Builder{}
+	line := func(row []string) {
+		text := ""
+		for i, cell := range row {
+			if i > 0 {
+				text += " | "
+			}
+			padding := strings.Repeat(" ", widths[i]-len(cell))
+			if i == 0 {
+				// Names are left aligned, values right aligned
+				text += cell + padding
+			} else {
+				text += padding + cell
+			}
+		}
+		sb.WriteString(strings.TrimRight(text, " ") + "\n")
+	}
+	separator := func() {
+		for i, width := range widths {
+			if i > 0 {
+				sb.WriteString("-+-")
+			}
+			sb.WriteString(strings.Repeat("-", width))
+		}
+		sb.WriteString("\n")
+	}
+
+	line(grid.Header)
+	line(grid.Costs)
+	separator()
+	for _, row := range grid.Rows {
+		line(row)
+	}
+	separator()
+	line(grid.Z)
+	line(grid.CZ)
+
+	return sb.String()
+}
+
+// grid Lay the tableau out as rows of cells: the basis column, the C_B column, one column per variable and the b column
+func (t *Tableau) grid(fractions bool) tableauGrid {
+	format := func(value float64) string {
+		return formatTableauValue(value, fractions)
+	}
+	return newTableauGrid(t.NamesRow, t.BasisNames, len(t.ConstraintRows), func(row, column int) string {
+		switch {
+		case row == gridCostRow:
+			return format(t.ObjectiveRow.Values[column])
+		case row == gridZRow && column == gridValueColumn:
+			return format(t.TableauValue)
+		case row == gridZRow:
+			return format(t.ZRow.Values[column])
+		case row == gridCZRow:
+			return format(t.CZRow.Values[column])
+		case column == gridBasisCostColumn:
+			return format(t.BasisColumn.Values[row])
+		case column == gridValueColumn:
+			return format(t.BColumn.Values[row])
+		}
+		return format(t.ConstraintRows[row].Values[column])
+	})
+}
+
+// Rows and columns of the grid outside of the constraint rows and variable columns, see newTableauGrid
+const (
+	gridCostRow         = -1
+	gridZRow            = -2
+	gridCZRow           = -3
+	gridBasisCostColumn = -1
+	gridValueColumn     = -2
+)
+
+// newTableauGrid Lay out a tableau with the given variables and basis, taking each value from cell. Constraint rows
+// and variable columns are passed to cell by index, the other rows and columns by the grid constants.
+func newTableauGrid(names, basis []string, rowCount int, cell func(row, column int) string) tableauGrid {
+	grid := tableauGrid{columnCount: len(names) + 3}
+
+	grid.Header = append(append([]string{"Basis", "C_B"}, names...), "b")
+
+	grid.Costs = []string{"C_j", ""}
+	for j := range names {
+		grid.Costs = append(grid.Costs, cell(gridCostRow, j))
+	}
+	grid.Costs = append(grid.Costs, "")
+
+	for i := 0; i < rowCount; i++ {
+		row := []string{basis[i], cell(i, gridBasisCostColumn)}
+		for j := range names {
+			row = append(row, cell(i, j))
+		}
+		grid.Rows = append(grid.Rows, append(row, cell(i, gridValueColumn)))
+	}
+
+	grid.Z = []string{"Z", ""}
+	for j := range names {
+		grid.Z = append(grid.Z, cell(gridZRow, j))
+	}
+	grid.Z = append(grid.Z, cell(gridZRow, gridValueColumn))
+
+	grid.CZ = []string{"C-Z", ""}
+	for j := range names {
+		grid.CZ = append(grid.CZ, cell(gridCZRow, j))
+	}
+	grid.CZ = append(grid.CZ, "")
+
+	return grid
+}
+
+// all Get every row of the grid in display order
+func (g tableauGrid) all() [][]string {
+	rows := [][]string{g.Header, g.Costs}
+	rows = append(rows, g.Rows...)
+	return append(rows, g.Z, g.CZ)
+}
+
+// formatTableauValue Format a tableau entry, writing big-M multiples in terms of M
+func formatTableauValue(value float64, fractions bool) string {
+	format := formatNumber
+	if fractions {
+		format = formatFraction
+	}
+
+	if math.Abs(value) >= bigM/1e6 {
+		switch multiple := value / bigM; multiple {
+		case 1:
+			return "M"
+		case -1:
+			return "-M"
+		default:
+			return format(multiple) + "M"
+		}
+	}
+	return format(value)
+}
+
+// formatFraction Format a value as a fraction when it is close to one with a small denominator, otherwise as a decimal
+func formatFraction(value float64) string {
+	if value == math.Trunc(value) {
+		return formatNumber(value)
+	}
+
+	// Continued fraction expansion, stopping at the first convergent that is close enough
+	h, hPrevious := 1.0, 0.0
+	k, kPrevious := 0.0, 1.0
+	x := math.Abs(value)
+	for i := 0; i < 32; i++ {
+		a := math.Floor(x)
+		h, hPrevious = a*h+hPrevious, h
+		k, kPrevious = a*k+kPrevious, k
+		if k > 10000 {
+			break
+		}
+		if math.Abs(h/k-math.Abs(value)) < 1e-9 {
+			fraction := big.NewRat(int64(h), int64(k))
+			if value < 0 {
+				fraction.Neg(fraction)
+			}
+			return fraction.RatString()
+		}
+		if x-a < 1e-12 {
+			break
+		}
+		x = 1 / (x - a)
+	}
+	return formatNumber(value)
+}
+
+// latex Render the tableau as a LaTeX tabular, boxing the entry at the pivot row and column if they are not negative
+func (t *Tableau) latex(fractions bool, pivotRow, pivotColumn int) string {
+	grid := t.grid(fractions)
+	variables := len(t.NamesRow)
+
+	sb := strings.Builder{}
+	sb.WriteString("\\begin{tabular}{l|r|" + strings.Repeat("r", variables) + "|r}\n")
+
+	row := func(cells []string, names bool, pivot int) {
+		for i, cell := range cells {
+			if i > 0 {
+				sb.WriteString(" & ")
+			}
+			switch {
+			case i == 0 || names:
+				sb.WriteString(latexName(cell))
+			case pivot >= 0 && i-2 == pivot:
+				sb.WriteString("\\boxed{" + latexValue(cell) + "}")
+			default:
+				sb.WriteString(latexValue(cell))
+			}
+		}
+		sb.WriteString(" \\\\\n")
+	}
+
+	row(grid.Header, true, -1)
+	row(grid.Costs, false, -1)
+	sb.WriteString("\\hline\n")
+	for i, r := range grid.Rows {
+		pivot := -1
+		if i == pivotRow {
+			pivot = pivotColumn
+		}
+		row(r, false, pivot)
+	}
+	sb.WriteString("\\hline\n")
+	row(grid.Z, false, -1)
+	row(grid.CZ, false, -1)
+	sb.WriteString("\\end{tabular}\n")
+
+	return sb.String()
+}
+
+// latexNamePattern Matches names such as x1 that are typeset with a subscript
+var latexNamePattern = regexp.MustCompile(`^([A-Za-z]+)_?([0-9]+)$`)
+
+// latexName Typeset a variable or row name
+func latexName(name string) string {
+	switch name {
+	case "":
+		return ""
+	case "C_B":
+		return "$C_B$"
+	case "C_j":
+		return "$C_j$"
+	case "C-Z":
+		return "$C_j - Z_j$"
+	case "Z", "b":
+		return "$" + name + "$"
+	}
+	if latexNamePattern.MatchString(name) {
+		return "$" + latexMathName(name) + "$"
+	}
+	return latexMathName(name)
+}
+
+// latexMathName Typeset a variable name for use in math mode
+func latexMathName(name string) string {
+	if m := latexNamePattern.FindStringSubmatch(name); m != nil {
+		return fmt.Sprintf("%v_{%v}", m[1], m[2])
+	}
+	return "\\text{" + latexEscape(name) + "}"
+}
+
+// latexValue Typeset a formatted tableau value, turning fractions into \frac and keeping M symbolic
+func latexValue(cell string) string {
+	if cell == "" {
+		return ""
+	}
+	sign := ""
+	if strings.HasPrefix(cell, "-") {
+		sign, cell = "-", cell[1:]
+	}
+	suffix := ""
+	if strings.HasSuffix(cell, "M") {
+		suffix, cell = "M", strings.TrimSuffix(cell, "M")
+	}
+	if numerator, denominator, ok := strings.Cut(cell, "/"); ok {
+		cell = fmt.Sprintf("\\frac{%v}{%v}", numerator, denominator)
+	}
+	return "$" + sign + cell + suffix + "$"
+}
+
+// latexEscape Escape the characters that are special in LaTeX text
+func latexEscape(s string) string {
+	return strings.NewReplacer(
+		"\\", "\\textbackslash{}", "&", "\\&", "%", "\\%", "$", "\\$", "#", "\\#",
+		"_", "\\_", "{", "\\{", "}", "\\}", "~", "\\textasciitilde{}", "^", "\\textasciicircum{}",
+	).Replace(s)
+}
@@ -0,0 +1,199 @@
+package gulp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// IterationKind What an iteration of the simplex method did
+type IterationKind int
+
+const (
+	// IterationPivot The entering variable replaced the leaving variable in the basis
+	IterationPivot IterationKind = iota
+	// IterationBound The entering variable, the slack of a range constraint, moved to the other end of its range
+	// before any basic variable left, so there was no pivot
+	IterationBound
+)
+
+func (k IterationKind) String() string {
+	switch k {
+	case IterationPivot:
+		return "pivot"
+	case IterationBound:
+		return "bound"
+	default:
+		return "unknown"
+	}
+}
+
+// Iteration A single step of the simplex method, a pivot or a bound move. A bound move has no leaving variable and
+// a PivotRow of -1.
+type Iteration struct {
+	Number       int
+	Kind         IterationKind
+	Entering     string
+	Leaving      string
+	PivotRow     int
+	PivotColumn  int
+	PivotElement float64
+	Ratios       []float64 // The ratio test for each row, +Inf where the row does not limit the entering variable
+	Tableau      *Tableau  // The tableau before the iteration
+}
+
+// Trace A record of every iteration of a solve, see WithTrace
+type Trace struct {
+	Iterations []Iteration
+	Final      *Tableau
+	Status     LpStatus
+
+	// Fractions Show values as fractions in the text and LaTeX exports
+	Fractions bool
+}
+
+// WriteText Write the trace as a sequence of plain text tableaux
+func (tr *Trace) WriteText(w io.Writer) error {
+	sb := strings.Builder{}
+	for _, it := range tr.Iterations {
+		if it.Kind == IterationBound {
+			sb.WriteString(fmt.Sprintf("Iteration %d: %v moves to the other end of its range\n", it.Number, it.Entering))
+		} else {
+			sb.WriteString(fmt.Sprintf("Iteration %d: %v enters, %v leaves, pivot element %v\n",
+				it.Number, it.Entering, it.Leaving, formatTableauValue(it.PivotElement, tr.Fractions)))
+		}
+		sb.WriteString(it.Tableau.Format(tr.Fractions))
+
+		ratios := make([]string, len(it.Ratios))
+		for i, ratio := range it.Ratios {
+			ratios[i] = fmt.Sprintf("%v = %v", it.Tableau.BasisNames[i], tr.formatRatio(ratio))
+		}
+		sb.WriteString("Ratios: " + strings.Join(ratios, ", ") + "\n\n")
+	}
+
+	if tr.Final != nil {
+		sb.WriteString(fmt.Sprintf("Final tableau: %v\n", tr.Status))
+		sb.WriteString(tr.Final.Format(tr.Fractions))
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// WriteJSON Write the trace as JSON, ratios that do not limit the entering variable are written as null and the kind
+// of each iteration as "pivot" or "bound"
+func (tr *Trace) WriteJSON(w io.Writer) error {
+	type jsonIteration struct {
+		Number       int         `json:"number"`
+		Kind         string      `json:"kind"`
+		Entering     string      `json:"entering"`
+		Leaving      string      `json:"leaving"`
+		PivotRow     int         `json:"pivotRow"`
+		PivotColumn  int         `json:"pivotColumn"`
+		PivotElement float64     `json:"pivotElement"`
+		Ratios       []*float64  `json:"ratios"`
+		Tableau      jsonTableau `json:"tableau"`
+	}
+	type jsonTrace struct {
+		Status     string          `json:"status"`
+		Iterations []jsonIteration `json:"iterations"`
+		Final      *jsonTableau    `json:"final,omitempty"`
+	}
+
+	out := jsonTrace{Status: tr.Status.String(), Iterations: []jsonIteration{}}
+	for _, it := range tr.Iterations {
+		ratios := make([]*float64, len(it.Ratios))
+		for i := range it.Ratios {
+			if !math.IsInf(it.Ratios[i], 0) {
+				ratios[i] = &it.Ratios[i]
+			}
+		}
+		out.Iterations = append(out.Iterations, jsonIteration{
+			Number:       it.Number,
+			Kind:         it.Kind.String(),
+			Entering:     it.Entering,
+			Leaving:      it.Leaving,
+			PivotRow:     it.PivotRow,
+			PivotColumn:  it.PivotColumn,
+			PivotElement: it.PivotElement,
+			Ratios:       ratios,
+			Tableau:      newJSONTableau(it.Tableau),
+		})
+	}
+	if tr.Final != nil {
+		final := newJSONTableau(tr.Final)
+		out.Final = &final
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out)
+}
+
+// WriteLaTeX Write the trace as a LaTeX tabular per iteration, with the pivot element boxed
+func (tr *Trace) WriteLaTeX(w io.Writer) error {
+	sb := strings.Builder{}
+	for _, it := range tr.Iterations {
+		if it.Kind == IterationBound {
+			sb.WriteString(fmt.Sprintf("%% Iteration %d: %v moves to the other end of its range\n", it.Number, it.Entering))
+			sb.WriteString(fmt.Sprintf("\\paragraph{Iteration %d} %v moves to the other end of its range.\n\n",
+				it.Number, latexName(it.Entering)))
+			sb.WriteString(it.Tableau.latex(tr.Fractions, -1, -1))
+			sb.WriteString("\n")
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("%% Iteration %d: %v enters, %v leaves\n", it.Number, it.Entering, it.Leaving))
+		sb.WriteString(fmt.Sprintf("\\paragraph{Iteration %d} %v enters the basis and %v leaves.\n\n",
+			it.Number, latexName(it.Entering), latexName(it.Leaving)))
+		sb.WriteString(it.Tableau.latex(tr.Fractions, it.PivotRow, it.PivotColumn))
+		sb.WriteString("\n")
+	}
+
+	if tr.Final != nil {
+		sb.WriteString(fmt.Sprintf("\\paragraph{Final tableau} %v.\n\n", tr.Status))
+		sb.WriteString(tr.Final.latex(tr.Fractions, -1, -1))
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func (tr *Trace) formatRatio(ratio float64) string {
+	if math.IsInf(ratio, 0) {
+		return "-"
+	}
+	return formatTableauValue(ratio, tr.Fractions)
+}
+
+// jsonTableau The JSON form of a tableau
+type jsonTableau struct {
+	Variables  []string    `json:"variables"`
+	Costs      []float64   `json:"costs"`
+	Basis      []string    `json:"basis"`
+	BasisCosts []float64   `json:"basisCosts"`
+	Rows       [][]float64 `json:"rows"`
+	B          []float64   `json:"b"`
+	Z          []float64   `json:"z"`
+	CZ         []float64   `json:"cz"`
+	Value      float64     `json:"value"`
+}
+
+func newJSONTableau(t *Tableau) jsonTableau {
+	rows := make([][]float64, len(t.ConstraintRows))
+	for i, r := range t.ConstraintRows {
+		rows[i] = r.Values
+	}
+	return jsonTableau{
+		Variables:  t.NamesRow,
+		Costs:      t.ObjectiveRow.Values,
+		Basis:      t.BasisNames,
+		BasisCosts: t.BasisColumn.Values,
+		Rows:       rows,
+		B:          t.BColumn.Values,
+		Z:          t.ZRow.Values,
+		CZ:         t.CZRow.Values,
+		Value:      t.TableauValue,
+	}
+}
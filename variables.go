@@ -0,0 +1,109 @@
+package gulp
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// reservedNamePattern Matches the names generated for slack (s1, s2, ...), artificial (a1, a2, ...) and elastic
+// (s1+, s1-, ...) variables
+var reservedNamePattern = regexp.MustCompile(`^[sa][0-9]+[+-]?$`)
+
+// AddVariable Register variables with the linear program, each is given the next free index
+func (lp *LinearProgram) AddVariable(variables ...LpVariable) *LinearProgram {
+	for _, v := range variables {
+		lp.registerVariable(v)
+	}
+	return lp
+}
+
+// Variables Get the registered variables in index order
+func (lp *LinearProgram) Variables() []LpVariable {
+	return append([]LpVariable{}, lp.variables...)
+}
+
+// Variable Look up a registered variable by name
+func (lp *LinearProgram) Variable(name string) (LpVariable, bool) {
+	i, ok := lp.variableIndex[name]
+	if !ok {
+		return LpVariable{}, false
+	}
+	return lp.variables[i], true
+}
+
+// VariableIndex Get the index of a registered variable, or -1 if there is no such variable
+func (lp *LinearProgram) VariableIndex(name string) int {
+	i, ok := lp.variableIndex[name]
+	if !ok {
+		return -1
+	}
+	return i
+}
+
+// registerVariable Add a variable to the registry, panicking if it conflicts with an existing or generated variable.
+// Registering the same variable twice is allowed and keeps the original index.
+func (lp *LinearProgram) registerVariable(v LpVariable) {
+	if v.Name == "" {
+		panic("Variable name must not be empty")
+	}
+	if v.IsSlack || v.IsArtificial {
+		panic(fmt.Sprintf("Variable %q: slack and artificial variables are generated by the solver", v.Name))
+	}
+	if reservedNamePattern.MatchString(v.Name) {
+		panic(fmt.Sprintf("Variable %q: name is reserved for slack and artificial variables", v.Name))
+	}
+
+	if i, ok := lp.variableIndex[v.Name]; ok {
+		if lp.variables[i] != v {
+			panic(fmt.Sprintf("Variable %q conflicts with an existing variable of the same name", v.Name))
+		}
+		return
+	}
+
+	if lp.variableIndex == nil {
+		lp.variableIndex = make(map[string]int)
+	}
+	lp.variableIndex[v.Name] = len(lp.variables)
+	lp.variables = append(lp.variables, v)
+}
+
+// registerTerms Register every variable used by the terms
+func (lp *LinearProgram) registerTerms(terms []LpTerm) {
+	for _, t := range terms {
+		lp.registerVariable(t.Variable)
+	}
+}
+
+// SetCategory Mark a variable as continuous, integer or binary, registering it if it is new. Variables are continuous
+// unless marked otherwise.
+func (lp *LinearProgram) SetCategory(variable LpVariable, category LpCategory) *LinearProgram {
+	switch category {
+	case LpContinuous, LpInteger, LpBinary:
+	default:
+		panic(fmt.Sprintf("Variable %q: unknown category %q", variable.Name, category))
+	}
+	lp.registerVariable(variable)
+	if lp.categories == nil {
+		lp.categories = make(map[string]LpCategory)
+	}
+	lp.categories[variable.Name] = category
+	return lp
+}
+
+// Category Get the category of a variable, LpContinuous unless it was set with SetCategory
+func (lp *LinearProgram) Category(variable LpVariable) LpCategory {
+	if category, ok := lp.categories[variable.Name]; ok {
+		return category
+	}
+	return LpContinuous
+}
+
+// hasIntegerVariables Check whether any variable is marked integer or binary
+func (lp *LinearProgram) hasIntegerVariables() bool {
+	for _, category := range lp.categories {
+		if category != LpContinuous {
+			return true
+		}
+	}
+	return false
+}
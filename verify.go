@@ -0,0 +1,178 @@
+package gulp
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"text/tabwriter"
+)
+
+// Verification The largest violation of each optimality condition found by Verify, zero where a condition holds
+// exactly. The dual and complementary slackness conditions are those of the objective function.
+type Verification struct {
+	Primal      float64 // Constraints, recomputed from their terms as they were added. Elastic constraints may be violated.
+	Bounds      float64 // x >= 0 for every variable, x <= 1 for binary variables, and x within a finite piecewise cost
+	Integrality float64 // Distance of integer variables from an integer, and values a special ordered set needs at zero
+	Dual        float64 // Sign restrictions on the duals and reduced costs, recomputed from the duals
+	// Complementary The largest product of a slack and its dual, or of a value and its reduced cost
+	Complementary float64
+	// Objective The difference between the reported objective value and the value recomputed from the variables
+	Objective float64
+}
+
+// Max Get the largest violation of any condition
+func (v *Verification) Max() float64 {
+	return math.Max(math.Max(math.Max(v.Primal, v.Bounds), math.Max(v.Integrality, v.Dual)), math.Max(v.Complementary, v.Objective))
+}
+
+// OK Check that every violation is within the tolerance
+func (v *Verification) OK(tolerance float64) bool {
+	return v.Max() <= tolerance
+}
+
+func (v *Verification) String() string {
+	sb := strings.Builder{}
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Primal feasibility:\t%v\n", formatNumber(v.Primal))
+	fmt.Fprintf(tw, "Bounds:\t%v\n", formatNumber(v.Bounds))
+	fmt.Fprintf(tw, "Integrality:\t%v\n", formatNumber(v.Integrality))
+	fmt.Fprintf(tw, "Dual feasibility:\t%v\n", formatNumber(v.Dual))
+	fmt.Fprintf(tw, "Complementary slackness:\t%v\n", formatNumber(v.Complementary))
+	fmt.Fprintf(tw, "Objective:\t%v\n", formatNumber(v.Objective))
+	_ = tw.Flush()
+	return sb.String()
+}
+
+// Verify Check an optimal result against the linear program without trusting the solver. The activity of each
+// constraint is recomputed from its terms, and the reduced costs from the duals, so a result that satisfies every
+// condition is optimal whatever went wrong inside the tableau. Returns an error if the result is not optimal or does
+// not cover every variable and constraint of the linear program. A variable with a piecewise cost is checked against
+// the slopes either side of its value, rather than a single reduced cost. Only the primal conditions are checked for
+// results found by branch and bound or with secondary objectives.
+func (lp *LinearProgram) Verify(result *Result) (*Verification, error) {
+	if result.Status() != LpStatusOptimal {
+		return nil, fmt.Errorf("only optimal results can be verified, got %v", result.Status())
+	}
+	for _, v := range lp.variables {
+		if _, ok := result.variableIndex[v.Name]; !ok {
+			return nil, fmt.Errorf("result has no value for variable %q", v.Name)
+		}
+	}
+	for _, c := range lp.Constraints {
+		if _, ok := result.constraintIndex[c.Name]; !ok {
+			return nil, fmt.Errorf("result has no dual for constraint %q", c.Name)
+		}
+	}
+
+	sense := float64(lp.Sense)
+	verification := &Verification{}
+	worst := func(field *float64, violation float64) {
+		*field = math.Max(*field, violation)
+	}
+
+	// The duals of <= rows in a maximisation and >= rows in a minimisation are non-negative, the reverse for the other
+	// inequality, and equalities are free
+	natural := LpConstraintLE
+	if lp.Sense == LpMinimise {
+		natural = LpConstraintGE
+	}
+
+	objective := 0.0
+	reducedCosts := make([]float64, len(lp.variables))
+	for _, t := range lp.userObjective() {
+		objective += t.Coefficient * result.Value(t.Variable)
+		reducedCosts[lp.variableIndex[t.Variable.Name]] += t.Coefficient
+	}
+	piecewise := make(map[int][]*LpPiecewise)
+	for _, p := range lp.PiecewiseCosts {
+		objective += p.Value(result.Value(p.Variable))
+		j := lp.variableIndex[p.Variable.Name]
+		piecewise[j] = append(piecewise[j], p)
+	}
+
+	for _, c := range lp.Constraints {
+		activity := 0.0
+		for _, t := range c.Terms {
+			activity += t.Coefficient * result.Value(t.Variable)
+		}
+		dual := result.Dual(c.Name)
+		for _, t := range c.Terms {
+			reducedCosts[lp.variableIndex[t.Variable.Name]] -= dual * t.Coefficient
+		}
+
+		slack := c.RightHandSide - activity
+		violated := violation(activity, c.ConstraintType, c.RightHandSide)
+		switch {
+		case c.Range != 0:
+			// The dual of a range constraint is free, its sign says which end of the range holds
+			violated = rangeViolation(activity, c.RightHandSide, c.Range)
+			if dual*sense < 0 {
+				slack = activity - c.lowerBound()
+			}
+		case c.ConstraintType == natural:
+			worst(&verification.Dual, -dual)
+		case c.ConstraintType == -natural:
+			worst(&verification.Dual, dual)
+		case c.ConstraintType == LpConstraintEQ:
+			slack = 0
+		}
+		if c.Elastic {
+			// The dual of an elastic constraint is capped by its penalty, which it reaches once the constraint breaks
+			objective -= sense * c.Penalty * violated
+			worst(&verification.Dual, math.Abs(dual)-c.Penalty)
+			if violated > 0 {
+				worst(&verification.Complementary, violated*(c.Penalty-math.Abs(dual)))
+				slack = 0
+			}
+		} else {
+			worst(&verification.Primal, violated)
+		}
+		worst(&verification.Complementary, math.Abs(slack*dual))
+	}
+
+	for j, v := range lp.variables {
+		value := result.Value(v)
+		worst(&verification.Bounds, -value)
+		switch lp.Category(v) {
+		case LpBinary:
+			worst(&verification.Bounds, value-1)
+			worst(&verification.Integrality, math.Abs(value-math.Round(value)))
+		case LpInteger:
+			worst(&verification.Integrality, math.Abs(value-math.Round(value)))
+		}
+
+		if len(piecewise[j]) > 0 {
+			// The cost of a piecewise variable bends at its breakpoints, so it is optimal if the objective would not
+			// improve on moving up along the slopes above it or down along those below
+			below, above, rises := reducedCosts[j], reducedCosts[j], true
+			for _, p := range piecewise[j] {
+				left, right, ok := p.slopesAt(value)
+				below, above, rises = below+left, above+right, rises && ok
+				if !ok {
+					worst(&verification.Bounds, value-p.Breakpoints[len(p.Breakpoints)-1])
+				}
+			}
+			if rises {
+				worst(&verification.Dual, sense*above)
+			}
+			worst(&verification.Complementary, math.Max(value*-sense*below, 0))
+			continue
+		}
+
+		// An optimal maximisation has no variable that would improve the objective if it rose
+		worst(&verification.Dual, sense*reducedCosts[j])
+		worst(&verification.Complementary, math.Abs(value*reducedCosts[j]))
+	}
+
+	for _, s := range lp.SpecialOrderedSets {
+		worst(&verification.Integrality, s.violation(result.Value))
+	}
+	if lp.hasIntegerVariables() || len(lp.SpecialOrderedSets) > 0 || !lp.piecewiseFits() || len(lp.SecondaryObjectives) > 0 {
+		// The duals of a branch and bound result are those of a relaxation with bounds of its own, and those of a
+		// result with secondary objectives are those of a model with rows or an objective of its own
+		verification.Dual, verification.Complementary = 0, 0
+	}
+
+	verification.Objective = math.Abs(result.ObjectiveValue() - objective)
+	return verification, nil
+}